@@ -2,65 +2,196 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"github.com/Parnishkaspb/avito/internal/config"
 	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/integrations/github"
+	"github.com/Parnishkaspb/avito/internal/integrations/gitlab"
+	"github.com/Parnishkaspb/avito/internal/lifecycle"
+	"github.com/Parnishkaspb/avito/internal/logger"
+	"github.com/Parnishkaspb/avito/internal/metrics"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/Parnishkaspb/avito/internal/reload"
+	"github.com/Parnishkaspb/avito/internal/retention"
 	myserver "github.com/Parnishkaspb/avito/internal/server"
-	"log"
+	"github.com/Parnishkaspb/avito/internal/tracing"
+	"github.com/Parnishkaspb/avito/internal/webhook"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
 	"os"
 	"os/signal"
-	"sync"
+	"strings"
 	"syscall"
 	"time"
 )
 
 func main() {
-	cfg := config.MustLoad()
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	os.Exit(run())
+}
+
+func run() int {
+	configPath := config.FetchConfigPath()
 
-	var wg sync.WaitGroup
+	if args := flag.Args(); len(args) > 0 {
+		return runCommand(configPath, args)
+	}
 
-	db := database.New(cfg.Postgre)
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		return 1
+	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := db.RunDatabase(ctx); err != nil {
-			log.Printf("Database error: %v", err)
-			cancel()
+	log, err := logger.New(cfg.Log, os.Stdout)
+	if err != nil {
+		slog.Error("invalid logger configuration", slog.Any("error", err))
+		return 1
+	}
+	slog.SetDefault(log)
+
+	// SIGHUP обрабатывается с этого момента, а не только после запуска остальных компонентов
+	reloader := reload.New(configPath, cfg, log)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Error("invalid tracing configuration", slog.Any("error", err))
+		return 1
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			log.Error("failed to flush traces", slog.Any("error", err))
 		}
 	}()
 
-	time.Sleep(2 * time.Second)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		server := myserver.New(cfg.Server, db, cfg.JWT.Secret)
-		if err := server.RunServer(ctx); err != nil {
-			log.Printf("Server error: %v", err)
-			cancel()
+	db := database.New(cfg.Postgre, log)
+
+	m := metrics.New()
+	m.RegisterPool(func() *pgxpool.Stat {
+		if db.Pool == nil {
+			return nil
 		}
-	}()
+		return db.Pool.Stat()
+	})
+	m.RegisterReviewLoad(db.GetOpenReviewLoad)
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	server := myserver.New(cfg, db, log, m)
 
-	<-stop
-	log.Println("Получен сигнал на выключение...")
+	reloader.OnReload(func(cfg *config.Config) (func(), error) {
+		return logger.PrepareLevel(log, cfg.Log.Level)
+	})
+	reloader.OnReload(func(cfg *config.Config) (func(), error) {
+		return func() { server.ApplyConfig(cfg) }, nil
+	})
 
-	cancel()
+	reviewerClients := map[string]integrations.ReviewerClient{}
+	if gh := cfg.Integration.GitHub; gh.APIToken != "" {
+		reviewerClients[models.ProviderGitHub] = github.NewClient(gh, cfg.Integration.ReviewerSync.Timeout)
+	}
+	if gl := cfg.Integration.GitLab; gl.APIToken != "" {
+		reviewerClients[models.ProviderGitLab] = gitlab.NewClient(gl, cfg.Integration.ReviewerSync.Timeout)
+	}
+	reviewerSyncer := integrations.NewReviewerSyncer(db, reviewerClients, cfg.Integration.ReviewerSync, log)
 
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+	manager := lifecycle.New(log, cfg.Lifecycle.StartTimeout, cfg.Lifecycle.StopTimeout)
+	manager.Add(lifecycle.Component{Name: "reload", Run: reloader.Run})
+	manager.Add(lifecycle.Component{Name: "database", Run: db.RunDatabase, Ready: db.Ready})
+	manager.Add(lifecycle.Component{Name: "server", Run: server.RunServer, Ready: server.Ready})
+	manager.Add(lifecycle.Component{Name: "audit", Run: retention.New("audit", cfg.Audit.Retention, cfg.Audit.PurgeInterval, db.PurgeAuditLog, log).Run})
+	manager.Add(lifecycle.Component{Name: "webhooks", Run: webhook.NewDispatcher(db, cfg.Webhooks, log).Run})
+	manager.Add(lifecycle.Component{Name: "reviewer_sync", Run: reviewerSyncer.Run})
+	deliveries := cfg.Integration.Deliveries
+	manager.Add(lifecycle.Component{Name: "integration_deliveries", Run: retention.New("integration_deliveries", deliveries.Retention, deliveries.PurgeInterval, db.PurgeIntegrationDeliveries, log).Run})
+
+	if err := manager.Run(ctx); err != nil {
+		log.Error("application stopped with error", slog.Any("error", err))
+		return lifecycle.ExitCode(err)
+	}
+
+	log.Info("all components stopped")
+	return 0
+}
+
+func runCommand(configPath string, args []string) int {
+	if len(args) == 2 && args[0] == "config" && args[1] == "print" {
+		cfg, err := config.Load(configPath)
+		if cfg != nil {
+			if printErr := config.Print(os.Stdout, cfg); printErr != nil {
+				fmt.Fprintln(os.Stderr, printErr)
+				return 1
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(args) >= 3 && len(args) <= 4 && args[0] == "admin" && args[1] == "bootstrap" {
+		name := args[2]
+		if len(args) == 4 {
+			name = args[3]
+		}
+		return bootstrapOrgAdmin(configPath, args[2], name)
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command: %s\nusage: app [-config path] [config print | admin bootstrap <user_id> [name]]\n", strings.Join(args, " "))
+	return 2
+}
+
+func bootstrapOrgAdmin(configPath, userID, name string) int {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		return 1
+	}
+
+	log, err := logger.New(cfg.Log, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db := database.New(cfg.Postgre, log)
+	if err := db.Connect(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	if err := db.BootstrapOrgAdmin(ctx, userID, name); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// /login не выдаёт org_admin, поэтому токен администратора выпускается здесь
+	role, err := db.CheckRoleUser(ctx, userID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	tokens, err := myserver.NewTokenService(cfg.JWT).GenerateTokenPair(userID, name, role, true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
 
-	select {
-	case <-done:
-		log.Println("Все компонены были отключены")
-	case <-time.After(10 * time.Second):
-		log.Println("Выключение по времени")
+	fmt.Fprintf(os.Stdout, "user %s is now an organization admin\n", userID)
+	if err := json.NewEncoder(os.Stdout).Encode(tokens); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	return 0
 }
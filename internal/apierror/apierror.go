@@ -0,0 +1,107 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/i18n"
+)
+
+type Error struct {
+	Code   string
+	Status int
+	Key    string
+	Params map[string]string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s (%v)", e.Code, e.Message(i18n.English), e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message(i18n.English))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Message(lang string) string {
+	key := e.Key
+	if key == "" {
+		key = e.Code
+	}
+
+	return i18n.Translate(lang, key, e.Params)
+}
+
+func New(code string, status int) *Error {
+	return &Error{
+		Code:   code,
+		Status: status,
+	}
+}
+
+func NewKey(code string, status int, key string) *Error {
+	return &Error{
+		Code:   code,
+		Status: status,
+		Key:    key,
+	}
+}
+
+func Wrap(err error, code string, status int, key string) *Error {
+	return &Error{
+		Code:   code,
+		Status: status,
+		Key:    key,
+		Err:    err,
+	}
+}
+
+func Validation(key string, params map[string]string) *Error {
+	return &Error{
+		Code:   constants.VALIDATION_ERROR,
+		Status: http.StatusBadRequest,
+		Key:    key,
+		Params: params,
+	}
+}
+
+func Internal(err error) *Error {
+	return Wrap(err, constants.INTERNAL_ERROR, http.StatusInternalServerError, "")
+}
+
+var (
+	ErrNotFound     = New(constants.NOT_FOUND, http.StatusNotFound)
+	ErrUnauthorized = New(constants.UNAUTHORIZED, http.StatusUnauthorized)
+	ErrAdminOnly    = NewKey(constants.UNAUTHORIZED, http.StatusUnauthorized, "auth.admin_required")
+	ErrOrgAdminOnly = NewKey(constants.FORBIDDEN, http.StatusForbidden, "auth.org_admin_required")
+	ErrInvalidKey   = NewKey(constants.UNAUTHORIZED, http.StatusUnauthorized, "auth.invalid_api_key")
+	ErrScopeDenied  = NewKey(constants.FORBIDDEN, http.StatusForbidden, "auth.scope_required")
+	ErrTeamExists   = New(constants.TEAM_EXISTS, http.StatusBadRequest)
+	ErrPRExists     = New(constants.PR_EXISTS, http.StatusConflict)
+	ErrPRMerged     = New(constants.PR_MERGED, http.StatusConflict)
+	ErrPRClosed     = New(constants.PR_CLOSED, http.StatusConflict)
+	ErrNotAssigned  = New(constants.NOT_ASSIGNED, http.StatusConflict)
+	ErrNoCandidate  = New(constants.NO_CANDIDATE, http.StatusConflict)
+
+	ErrNotServiceAccount = NewKey(constants.VALIDATION_ERROR, http.StatusBadRequest, "validation.not_service_account")
+	ErrExternalIDTaken   = NewKey(constants.VALIDATION_ERROR, http.StatusBadRequest, "validation.external_id_taken")
+	ErrOIDCSubjectTaken  = NewKey(constants.VALIDATION_ERROR, http.StatusBadRequest, "validation.oidc_subject_taken")
+
+	ErrIdempotencyInProgress = New(constants.IDEMPOTENCY_IN_PROGRESS, http.StatusConflict)
+
+	ErrPayloadTooLarge = New(constants.PAYLOAD_TOO_LARGE, http.StatusRequestEntityTooLarge)
+	ErrRequestTimeout  = New(constants.REQUEST_TIMEOUT, http.StatusServiceUnavailable)
+	ErrRateLimited     = New(constants.RATE_LIMITED, http.StatusTooManyRequests)
+
+	ErrSSOFailed      = NewKey(constants.UNAUTHORIZED, http.StatusUnauthorized, "auth.sso_failed")
+	ErrSSOState       = NewKey(constants.UNAUTHORIZED, http.StatusUnauthorized, "auth.sso_invalid_state")
+	ErrSSOUnknownUser = NewKey(constants.FORBIDDEN, http.StatusForbidden, "auth.sso_unknown_user")
+	ErrSSOInactive    = NewKey(constants.FORBIDDEN, http.StatusForbidden, "auth.sso_inactive_user")
+
+	ErrInvalidSignature    = NewKey(constants.UNAUTHORIZED, http.StatusUnauthorized, "auth.invalid_signature")
+	ErrInvalidWebhookToken = NewKey(constants.UNAUTHORIZED, http.StatusUnauthorized, "auth.invalid_webhook_token")
+)
@@ -0,0 +1,286 @@
+// Package codeowners разбирает файлы CODEOWNERS в формате GitHub и GitLab и находит
+// владельцев изменённых путей.
+//
+// Поддерживаются шаблоны в стиле gitignore (*, ?, **, ведущий и завершающий /),
+// комментарии, экранирование через \ и секции GitLab ([Section] @owners). Внутри
+// секции действует последнее подошедшее правило; владельцы разных секций объединяются.
+package codeowners
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ParseError указывает на строку файла, которую не удалось разобрать.
+type ParseError struct {
+	Line   int
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("codeowners: line %d: %s", e.Line, e.Reason)
+}
+
+type Rule struct {
+	Pattern string
+	Owners  []string
+	Line    int
+
+	re *regexp.Regexp
+}
+
+func (r Rule) Match(path string) bool {
+	return r.re.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+type section struct {
+	rules []Rule
+}
+
+type File struct {
+	sections []section
+}
+
+func Parse(content string) (*File, error) {
+	f := &File{sections: []section{{}}}
+	var defaults []string
+
+	for i, raw := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		line := strings.TrimSpace(strings.TrimSuffix(raw, "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			owners, err := parseSection(line)
+			if err != nil {
+				return nil, &ParseError{Line: lineNo, Reason: err.Error()}
+			}
+			defaults = owners
+			f.sections = append(f.sections, section{})
+			continue
+		}
+
+		fields := splitFields(line)
+		pattern, owners := fields[0], fields[1:]
+		// комментарий в конце строки
+		if i := slices.IndexFunc(owners, func(o string) bool { return strings.HasPrefix(o, "#") }); i >= 0 {
+			owners = owners[:i]
+		}
+		for _, owner := range owners {
+			if !validOwner(owner) {
+				return nil, &ParseError{Line: lineNo, Reason: fmt.Sprintf("invalid owner %q", owner)}
+			}
+		}
+		if len(owners) == 0 {
+			owners = defaults
+		}
+
+		re, err := compile(pattern)
+		if err != nil {
+			return nil, &ParseError{Line: lineNo, Reason: err.Error()}
+		}
+
+		s := &f.sections[len(f.sections)-1]
+		s.rules = append(s.rules, Rule{Pattern: pattern, Owners: owners, Line: lineNo, re: re})
+	}
+
+	return f, nil
+}
+
+// Rules возвращает правила всех секций в порядке объявления.
+func (f *File) Rules() []Rule {
+	var rules []Rule
+	for _, s := range f.sections {
+		rules = append(rules, s.rules...)
+	}
+	return rules
+}
+
+// Owners возвращает владельцев пути. Правило без владельцев снимает владение в своей секции.
+func (f *File) Owners(path string) []string {
+	var owners []string
+	for _, s := range f.sections {
+		for i := len(s.rules) - 1; i >= 0; i-- {
+			if s.rules[i].Match(path) {
+				owners = appendUnique(owners, s.rules[i].Owners...)
+				break
+			}
+		}
+	}
+	return owners
+}
+
+// OwnersOf объединяет владельцев всех путей в порядке первого появления.
+func (f *File) OwnersOf(paths []string) []string {
+	var owners []string
+	for _, path := range paths {
+		owners = appendUnique(owners, f.Owners(path)...)
+	}
+	return owners
+}
+
+// parseSection разбирает заголовок секции GitLab: "^[Name][2] @owner ...".
+func parseSection(line string) ([]string, error) {
+	rest := strings.TrimPrefix(line, "^")
+	end := strings.Index(rest, "]")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated section header")
+	}
+	if strings.TrimSpace(rest[1:end]) == "" {
+		return nil, fmt.Errorf("empty section name")
+	}
+	rest = rest[end+1:]
+
+	// необязательное число одобрений: [2]
+	if strings.HasPrefix(rest, "[") {
+		end = strings.Index(rest, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated approvals count")
+		}
+		rest = rest[end+1:]
+	}
+
+	owners := strings.Fields(rest)
+	for _, owner := range owners {
+		if !validOwner(owner) {
+			return nil, fmt.Errorf("invalid owner %q", owner)
+		}
+	}
+	return owners, nil
+}
+
+// splitFields делит строку по пробелам, учитывая экранирование "\ " в шаблоне.
+func splitFields(line string) []string {
+	var (
+		fields  []string
+		current strings.Builder
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ' ' || r == '\t':
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+// validOwner принимает @login, @org/team и email.
+func validOwner(owner string) bool {
+	if name, ok := strings.CutPrefix(owner, "@"); ok {
+		return name != "" && !strings.ContainsAny(name, "@")
+	}
+	local, domain, ok := strings.Cut(owner, "@")
+	return ok && local != "" && strings.Contains(domain, ".")
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") {
+		return nil, fmt.Errorf("negated pattern %q is not supported", pattern)
+	}
+	if strings.HasPrefix(pattern, "[") {
+		return nil, fmt.Errorf("character class in pattern %q is not supported", pattern)
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	body := strings.TrimSuffix(pattern, "/")
+	anchored := strings.HasPrefix(body, "/") || strings.Contains(strings.TrimPrefix(body, "/"), "/")
+	body = strings.TrimPrefix(body, "/")
+	if body == "" {
+		return regexp.MustCompile(".*"), nil
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+
+	segments := strings.Split(body, "/")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		if seg == "**" {
+			if last {
+				b.WriteString(".*")
+			} else {
+				b.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+
+		if err := writeSegment(&b, seg); err != nil {
+			return nil, err
+		}
+		if !last {
+			b.WriteString("/")
+		}
+	}
+
+	last := segments[len(segments)-1]
+	switch {
+	case last == "**":
+		// ".../**" уже покрывает всё содержимое
+	case dirOnly:
+		b.WriteString("/.*")
+	case !strings.Contains(last, "*"):
+		// шаблон может указывать на каталог: тогда он покрывает всё внутри
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+func writeSegment(b *strings.Builder, seg string) error {
+	for i := 0; i < len(seg); i++ {
+		switch seg[i] {
+		case '\\':
+			if i+1 == len(seg) {
+				return fmt.Errorf("trailing backslash")
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(seg[i : i+1]))
+		case '*':
+			b.WriteString("[^/]*")
+			// "**" внутри сегмента ведёт себя как "*"
+			for i+1 < len(seg) && seg[i+1] == '*' {
+				i++
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(seg[i : i+1]))
+		}
+	}
+	return nil
+}
+
+func appendUnique(dst []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(dst, v) {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
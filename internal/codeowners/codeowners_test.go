@@ -0,0 +1,117 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRule_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "README.md", true},
+		{"*", "cmd/app/main.go", true},
+		{"*.go", "internal/server/server.go", true},
+		{"*.go", "go.mod", false},
+		{"/docs/", "docs/api/openapi.yml", true},
+		{"/docs/", "internal/docs/readme.md", false},
+		{"docs/", "internal/docs/readme.md", true},
+		{"docs/", "docs", false},
+		{"docs/*", "docs/getting-started.md", true},
+		{"docs/*", "docs/build/troubleshooting.md", false},
+		{"internal/database", "internal/database/postgresql.go", true},
+		{"internal/database", "pkg/internal/database/x.go", false},
+		{"Makefile", "build/Makefile", true},
+		{"**/migrations", "internal/database/migrations/01-init.sql", true},
+		{"/internal/**/testdata/*.json", "internal/integrations/gitlab/testdata/mr.json", true},
+		{"/internal/**/testdata/*.json", "internal/testdata/mr.json", true},
+		{"/scripts/**", "scripts/ci/run.sh", true},
+		{"config.?aml", "config/config.yaml", true},
+		{`my\ file.txt`, "my file.txt", true},
+		{`\#notes`, "#notes", true},
+		{"/", "anything/at/all.go", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			f, err := Parse(tt.pattern + " @owner")
+			require.NoError(t, err)
+
+			rules := f.Rules()
+			require.Len(t, rules, 1)
+			assert.Equal(t, tt.want, rules[0].Match(tt.path))
+		})
+	}
+}
+
+func TestFile_OwnersLastMatchWins(t *testing.T) {
+	f, err := Parse(`
+# владельцы по умолчанию
+*                       @platform/core
+*.sql                   @dba admin@example.com
+/internal/database/     @alice @bob  # хранилище
+/internal/database/migrations/
+/docs/                  @writer
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"@platform/core"}, f.Owners("cmd/app/main.go"))
+	assert.Equal(t, []string{"@dba", "admin@example.com"}, f.Owners("scripts/seed.sql"))
+	assert.Equal(t, []string{"@alice", "@bob"}, f.Owners("/internal/database/postgresql.go"))
+	assert.Empty(t, f.Owners("internal/database/migrations/11-codeowners.sql"))
+
+	assert.Equal(t,
+		[]string{"@alice", "@bob", "@writer"},
+		f.OwnersOf([]string{"internal/database/integrations.go", "docs/api.md", "internal/database/reviewer_sync.go"}),
+	)
+}
+
+func TestFile_GitLabSections(t *testing.T) {
+	f, err := Parse(`
+*.go @backend
+
+[Documentation] @writer
+docs/
+README.md @alice
+
+^[Database][2] @dba
+*.sql
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"@backend"}, f.Owners("internal/models/user.go"))
+	assert.Equal(t, []string{"@writer"}, f.Owners("docs/guide.md"))
+	assert.Equal(t, []string{"@alice"}, f.Owners("README.md"))
+	assert.Equal(t, []string{"@dba"}, f.Owners("internal/database/migrations/01-init.sql"))
+	assert.Empty(t, f.Owners("Dockerfile"))
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"negation", "*.go @a\n!vendor/ @b", 2},
+		{"character class", "[abc].go @a", 1},
+		{"bad owner", "\n\n*.go alice", 3},
+		{"bad email", "*.go alice@localhost", 1},
+		{"bad section", "[Docs @writer", 1},
+		{"empty section", "[] @writer", 1},
+		{"trailing backslash", `docs\`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.content)
+
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.line, parseErr.Line)
+		})
+	}
+}
@@ -1,70 +1,515 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"github.com/ilyakaznacheev/cleanenv"
+	"net"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/Parnishkaspb/avito/internal/i18n"
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+const (
+	MinJWTSecretLength = 16
+	MaxReviewersCount  = 10
 )
 
 type Config struct {
-	Server  ServerConfig    `yaml:"server"`
-	Postgre PostreSQLConfig `yaml:"postgresql"`
-	JWT     JWTConfig       `yaml:"jwt"`
+	Server      ServerConfig      `yaml:"server"`
+	Postgre     PostreSQLConfig   `yaml:"postgresql"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	I18n        I18nConfig        `yaml:"i18n"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Lifecycle   LifecycleConfig   `yaml:"lifecycle"`
+	Reviewers   ReviewersConfig   `yaml:"reviewers"`
+	Reload      ReloadConfig      `yaml:"reload"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+	Audit       AuditConfig       `yaml:"audit"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"`
+	Integration IntegrationConfig `yaml:"integrations"`
 }
 
 type ServerConfig struct {
-	Port    int           `yaml:"port"`
-	Host    string        `yaml:"host"`
-	Timeout time.Duration `yaml:"timeout"`
+	Port    int           `yaml:"port" env:"SERVER_PORT" env-default:"8080"`
+	Host    string        `yaml:"host" env:"SERVER_HOST" env-default:"localhost"`
+	Timeout time.Duration `yaml:"timeout" env:"SERVER_TIMEOUT" env-default:"30s"`
+
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" env-default:"15s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" env-default:"5s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" env-default:"35s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" env-default:"60s"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" env-default:"15s"`
+	DrainDelay        time.Duration `yaml:"drain_delay" env:"SERVER_DRAIN_DELAY" env-default:"5s"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" env-default:"1048576"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES" env-default:"1048576"`
 }
 
 type PostreSQLConfig struct {
-	User     string `yaml:"user" env-required:"true"`
-	Password string `yaml:"password" env-required:"true"`
-	Host     string `yaml:"host" env-required:"true"`
-	Port     int    `yaml:"port" env-required:"true"`
-	DB       string `yaml:"db"   env-required:"true"`
-	SSLMode  string `yaml:"sslmode"  env-default:"disable"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
+	Host     string `yaml:"host" env:"DB_HOST" env-default:"localhost"`
+	Port     int    `yaml:"port" env:"DB_PORT" env-default:"5432"`
+	DB       string `yaml:"db" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE" env-default:"disable"`
+	DSN      string `yaml:"dsn" env:"DB_DSN"`
+
+	MaxConns          int32         `yaml:"max_conns" env:"DB_MAX_CONNS" env-default:"20"`
+	MinConns          int32         `yaml:"min_conns" env:"DB_MIN_CONNS" env-default:"2"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME" env-default:"1h"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME" env-default:"30m"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD" env-default:"1m"`
+	StatementTimeout  time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT" env-default:"30s"`
+	ApplicationName   string        `yaml:"application_name" env:"DB_APPLICATION_NAME" env-default:"avito"`
+
+	ConnectAttempts   int           `yaml:"connect_attempts" env:"DB_CONNECT_ATTEMPTS" env-default:"10"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" env:"DB_CONNECT_BACKOFF" env-default:"500ms"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff" env:"DB_CONNECT_MAX_BACKOFF" env-default:"10s"`
 }
 
 type JWTConfig struct {
-	Secret string `yaml:"jwt_secret" env-required:"true"`
+	Secret          string        `yaml:"jwt_secret" env:"JWT_SECRET"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL" env-default:"168h"`
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+}
+
+type I18nConfig struct {
+	DefaultLanguage string `yaml:"default_language" env:"I18N_DEFAULT_LANGUAGE" env-default:"en"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
+	Format string `yaml:"format" env:"LOG_FORMAT" env-default:"json"`
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	File         string  `yaml:"file" env:"TRACING_FILE"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env:"TRACING_OTLP_INSECURE"`
+	ServiceName  string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"avito"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+type LifecycleConfig struct {
+	StartTimeout time.Duration `yaml:"start_timeout" env:"LIFECYCLE_START_TIMEOUT" env-default:"60s"`
+	StopTimeout  time.Duration `yaml:"stop_timeout" env:"LIFECYCLE_STOP_TIMEOUT" env-default:"25s"`
+}
+
+type ReviewersConfig struct {
+	Count    int    `yaml:"count" env:"REVIEWERS_COUNT" env-default:"2"`
+	Strategy string `yaml:"strategy" env:"REVIEWERS_STRATEGY" env-default:"random"`
+}
+
+type ReloadConfig struct {
+	WatchInterval time.Duration `yaml:"watch_interval" env:"CONFIG_WATCH_INTERVAL" env-default:"0s"`
+}
+
+type RateLimitConfig struct {
+	Enabled        bool                     `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	TrustedProxies []string                 `yaml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES" env-separator:","`
+	Default        RateLimitRule            `yaml:"default" env-prefix:"RATE_LIMIT_DEFAULT_"`
+	PerIP          RateLimitRule            `yaml:"per_ip" env-prefix:"RATE_LIMIT_PER_IP_"`
+	Routes         map[string]RateLimitRule `yaml:"routes"`
+}
+
+type RateLimitRule struct {
+	RPS   float64 `yaml:"rps" env:"RPS" env-default:"10"`
+	Burst int     `yaml:"burst" env:"BURST" env-default:"20"`
+}
+
+type OIDCConfig struct {
+	Enabled       bool          `yaml:"enabled" env:"OIDC_ENABLED"`
+	Issuer        string        `yaml:"issuer" env:"OIDC_ISSUER"`
+	ClientID      string        `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret  string        `yaml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL   string        `yaml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes        []string      `yaml:"scopes" env:"OIDC_SCOPES" env-separator:"," env-default:"openid,profile,email"`
+	UserClaim     string        `yaml:"user_claim" env:"OIDC_USER_CLAIM" env-default:"sub"`
+	StateTTL      time.Duration `yaml:"state_ttl" env:"OIDC_STATE_TTL" env-default:"10m"`
+	AutoProvision bool          `yaml:"auto_provision" env:"OIDC_AUTO_PROVISION"`
+	GroupsClaim   string        `yaml:"groups_claim" env:"OIDC_GROUPS_CLAIM" env-default:"groups"`
+	TeamPrefix    string        `yaml:"team_prefix" env:"OIDC_TEAM_PREFIX" env-default:"team:"`
+}
+
+type AuditConfig struct {
+	Retention     time.Duration `yaml:"retention" env:"AUDIT_RETENTION" env-default:"2160h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"AUDIT_PURGE_INTERVAL" env-default:"1h"`
+}
+
+type IntegrationConfig struct {
+	GitHub       GitHubConfig       `yaml:"github"`
+	GitLab       GitLabConfig       `yaml:"gitlab"`
+	ReviewerSync ReviewerSyncConfig `yaml:"reviewer_sync"`
+	Deliveries   DeliveriesConfig   `yaml:"deliveries"`
+}
+
+type GitHubConfig struct {
+	Enabled       bool   `yaml:"enabled" env:"GITHUB_ENABLED"`
+	WebhookSecret string `yaml:"webhook_secret" env:"GITHUB_WEBHOOK_SECRET"`
+	APIURL        string `yaml:"api_url" env:"GITHUB_API_URL" env-default:"https://api.github.com"`
+	APIToken      string `yaml:"api_token" env:"GITHUB_API_TOKEN"`
+}
+
+type GitLabConfig struct {
+	Enabled      bool   `yaml:"enabled" env:"GITLAB_ENABLED"`
+	WebhookToken string `yaml:"webhook_token" env:"GITLAB_WEBHOOK_TOKEN"`
+	APIURL       string `yaml:"api_url" env:"GITLAB_API_URL"`
+	APIToken     string `yaml:"api_token" env:"GITLAB_API_TOKEN"`
+}
+
+// DeliveriesConfig — сколько хранить id обработанных доставок вебхуков code host; повтор доставки
+// старше retention применится заново.
+type DeliveriesConfig struct {
+	Retention     time.Duration `yaml:"retention" env:"INTEGRATION_DELIVERIES_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"INTEGRATION_DELIVERIES_PURGE_INTERVAL" env-default:"1h"`
+}
+
+type ReviewerSyncConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"REVIEWER_SYNC_POLL_INTERVAL" env-default:"2s"`
+	BatchSize    int           `yaml:"batch_size" env:"REVIEWER_SYNC_BATCH_SIZE" env-default:"20"`
+	Timeout      time.Duration `yaml:"timeout" env:"REVIEWER_SYNC_TIMEOUT" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"REVIEWER_SYNC_MAX_ATTEMPTS" env-default:"8"`
+	BackoffBase  time.Duration `yaml:"backoff_base" env:"REVIEWER_SYNC_BACKOFF_BASE" env-default:"10s"`
+	BackoffMax   time.Duration `yaml:"backoff_max" env:"REVIEWER_SYNC_BACKOFF_MAX" env-default:"30m"`
+}
+
+type WebhooksConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"WEBHOOKS_BATCH_SIZE" env-default:"20"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"10"`
+	BackoffBase  time.Duration `yaml:"backoff_base" env:"WEBHOOKS_BACKOFF_BASE" env-default:"10s"`
+	BackoffMax   time.Duration `yaml:"backoff_max" env:"WEBHOOKS_BACKOFF_MAX" env-default:"1h"`
 }
 
 func MustLoad() *Config {
-	path := fetchConfigPath()
-	fmt.Println(path)
+	return MustLoadByPath(FetchConfigPath())
+}
+
+func MustLoadByPath(path string) *Config {
+	cfg, err := Load(path)
+	if err != nil {
+		panic("failed to load config: " + err.Error())
+	}
+
+	return cfg
+}
+
+func Load(path string) (*Config, error) {
+	var cfg Config
+
 	if path == "" {
-		return &Config{}
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to read env: %w", err)
+		}
+	} else {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, fmt.Errorf("config path does not exist: %s", path)
+		}
+
+		if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
 	}
 
-	return MustLoadByPath(path)
+	return &cfg, cfg.Validate()
 }
 
-func MustLoadByPath(path string) *Config {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		panic("config path does not exist: " + path)
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
 	}
 
-	var cfg Config
+	if !validPort(c.Server.Port) {
+		add("server.port: must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.Server.Timeout <= 0 {
+		add("server.timeout: must be positive, got %s", c.Server.Timeout)
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	} {
+		if d.value <= 0 {
+			add("%s: must be positive, got %s", d.name, d.value)
+		}
+	}
+	if c.Server.WriteTimeout > 0 && c.Server.WriteTimeout < c.Server.Timeout {
+		add("server.write_timeout: must not be less than server.timeout (%s < %s)", c.Server.WriteTimeout, c.Server.Timeout)
+	}
+	if c.Server.DrainDelay < 0 {
+		add("server.drain_delay: must not be negative, got %s", c.Server.DrainDelay)
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		add("server.max_header_bytes: must be positive, got %d", c.Server.MaxHeaderBytes)
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		add("server.max_body_bytes: must be positive, got %d", c.Server.MaxBodyBytes)
+	}
+	if stop := c.Server.DrainDelay + c.Server.ShutdownTimeout; c.Lifecycle.StopTimeout > 0 && c.Lifecycle.StopTimeout < stop {
+		add("lifecycle.stop_timeout: must not be less than server.drain_delay + server.shutdown_timeout (%s < %s)", c.Lifecycle.StopTimeout, stop)
+	}
 
-	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
-		panic("failed to read config: " + err.Error())
+	if c.Postgre.DSN == "" {
+		if c.Postgre.User == "" {
+			add("postgresql.user: required when dsn is not set")
+		}
+		if c.Postgre.Host == "" {
+			add("postgresql.host: required when dsn is not set")
+		}
+		if !validPort(c.Postgre.Port) {
+			add("postgresql.port: must be between 1 and 65535, got %d", c.Postgre.Port)
+		}
+		if c.Postgre.DB == "" {
+			add("postgresql.db: required when dsn is not set")
+		}
 	}
+	if c.Postgre.MaxConns < 0 || c.Postgre.MinConns < 0 {
+		add("postgresql.max_conns/min_conns: must not be negative")
+	}
+	if c.Postgre.MaxConns > 0 && c.Postgre.MinConns > c.Postgre.MaxConns {
+		add("postgresql.min_conns: must not exceed max_conns (%d > %d)", c.Postgre.MinConns, c.Postgre.MaxConns)
+	}
+	if c.Postgre.ConnectAttempts < 0 {
+		add("postgresql.connect_attempts: must not be negative, got %d", c.Postgre.ConnectAttempts)
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"postgresql.max_conn_lifetime", c.Postgre.MaxConnLifetime},
+		{"postgresql.max_conn_idle_time", c.Postgre.MaxConnIdleTime},
+		{"postgresql.health_check_period", c.Postgre.HealthCheckPeriod},
+		{"postgresql.statement_timeout", c.Postgre.StatementTimeout},
+		{"postgresql.connect_backoff", c.Postgre.ConnectBackoff},
+		{"postgresql.connect_max_backoff", c.Postgre.ConnectMaxBackoff},
+		{"lifecycle.start_timeout", c.Lifecycle.StartTimeout},
+		{"lifecycle.stop_timeout", c.Lifecycle.StopTimeout},
+	} {
+		if d.value < 0 {
+			add("%s: must not be negative, got %s", d.name, d.value)
+		}
+	}
+
+	if len(c.JWT.Secret) < MinJWTSecretLength {
+		add("jwt.jwt_secret: must be at least %d characters long", MinJWTSecretLength)
+	}
+	if c.JWT.AccessTokenTTL <= 0 {
+		add("jwt.access_token_ttl: must be positive, got %s", c.JWT.AccessTokenTTL)
+	}
+	if c.JWT.RefreshTokenTTL < c.JWT.AccessTokenTTL {
+		add("jwt.refresh_token_ttl: must not be less than access_token_ttl (%s < %s)", c.JWT.RefreshTokenTTL, c.JWT.AccessTokenTTL)
+	}
+
+	if c.Reviewers.Count < 0 || c.Reviewers.Count > MaxReviewersCount {
+		add("reviewers.count: must be between 0 and %d, got %d", MaxReviewersCount, c.Reviewers.Count)
+	}
+	switch c.Reviewers.Strategy {
+	case "random", "least_loaded":
+	default:
+		add("reviewers.strategy: must be random or least_loaded, got %q", c.Reviewers.Strategy)
+	}
+
+	for _, proxy := range c.RateLimit.TrustedProxies {
+		if !validIPOrCIDR(proxy) {
+			add("rate_limit.trusted_proxies: invalid IP or CIDR %q", proxy)
+		}
+	}
+	if err := c.RateLimit.Default.validate(); err != nil {
+		add("rate_limit.default: %v", err)
+	}
+	if err := c.RateLimit.PerIP.validate(); err != nil {
+		add("rate_limit.per_ip: %v", err)
+	}
+	for route, rule := range c.RateLimit.Routes {
+		if err := rule.validate(); err != nil {
+			add("rate_limit.routes[%s]: %v", route, err)
+		}
+	}
+
+	if c.Reload.WatchInterval < 0 {
+		add("reload.watch_interval: must not be negative, got %s", c.Reload.WatchInterval)
+	}
+
+	if c.Audit.Retention < 0 {
+		add("audit.retention: must not be negative, got %s", c.Audit.Retention)
+	}
+	if c.Audit.PurgeInterval <= 0 {
+		add("audit.purge_interval: must be positive, got %s", c.Audit.PurgeInterval)
+	}
+
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"webhooks.poll_interval", c.Webhooks.PollInterval},
+		{"webhooks.timeout", c.Webhooks.Timeout},
+		{"webhooks.backoff_base", c.Webhooks.BackoffBase},
+	} {
+		if d.value <= 0 {
+			add("%s: must be positive, got %s", d.name, d.value)
+		}
+	}
+	if c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+		add("webhooks.backoff_max: must not be less than backoff_base (%s < %s)", c.Webhooks.BackoffMax, c.Webhooks.BackoffBase)
+	}
+	if c.Webhooks.BatchSize <= 0 {
+		add("webhooks.batch_size: must be positive, got %d", c.Webhooks.BatchSize)
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		add("webhooks.max_attempts: must be positive, got %d", c.Webhooks.MaxAttempts)
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		add("idempotency.ttl: must be positive, got %s", c.Idempotency.TTL)
+	}
+
+	if !i18n.Supported(c.I18n.DefaultLanguage) {
+		add("i18n.default_language: unsupported language %q", c.I18n.DefaultLanguage)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level: must be one of debug, info, warn, error, got %q", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		add("log.format: must be json or text, got %q", c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "file":
+		if c.Tracing.File == "" {
+			add("tracing.file: required for file exporter")
+		}
+	case "otlp":
+		if c.Tracing.OTLPEndpoint == "" {
+			add("tracing.otlp_endpoint: required for otlp exporter")
+		}
+	default:
+		add("tracing.exporter: must be one of none, stdout, file, otlp, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio: must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	if c.OIDC.Enabled {
+		if c.OIDC.Issuer == "" {
+			add("oidc.issuer: required when oidc is enabled")
+		}
+		if c.OIDC.ClientID == "" {
+			add("oidc.client_id: required when oidc is enabled")
+		}
+		if c.OIDC.RedirectURL == "" {
+			add("oidc.redirect_url: required when oidc is enabled")
+		}
+		switch c.OIDC.UserClaim {
+		case "sub", "email":
+		default:
+			add("oidc.user_claim: must be sub or email, got %q", c.OIDC.UserClaim)
+		}
+		if c.OIDC.StateTTL <= 0 {
+			add("oidc.state_ttl: must be positive, got %s", c.OIDC.StateTTL)
+		}
+	}
+
+	if c.Integration.GitHub.Enabled && c.Integration.GitHub.WebhookSecret == "" {
+		add("integrations.github.webhook_secret: required when github integration is enabled")
+	}
+
+	if c.Integration.GitLab.Enabled && c.Integration.GitLab.WebhookToken == "" {
+		add("integrations.gitlab.webhook_token: required when gitlab integration is enabled")
+	}
+	if c.Integration.GitHub.APIToken != "" && c.Integration.GitHub.APIURL == "" {
+		add("integrations.github.api_url: required when api_token is set")
+	}
+	if c.Integration.GitLab.APIToken != "" && c.Integration.GitLab.APIURL == "" {
+		add("integrations.gitlab.api_url: required when api_token is set")
+	}
+
+	sync := c.Integration.ReviewerSync
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"integrations.reviewer_sync.poll_interval", sync.PollInterval},
+		{"integrations.reviewer_sync.timeout", sync.Timeout},
+		{"integrations.reviewer_sync.backoff_base", sync.BackoffBase},
+	} {
+		if d.value <= 0 {
+			add("%s: must be positive, got %s", d.name, d.value)
+		}
+	}
+	if sync.BackoffMax < sync.BackoffBase {
+		add("integrations.reviewer_sync.backoff_max: must not be less than backoff_base (%s < %s)", sync.BackoffMax, sync.BackoffBase)
+	}
+	if sync.BatchSize <= 0 {
+		add("integrations.reviewer_sync.batch_size: must be positive, got %d", sync.BatchSize)
+	}
+	if sync.MaxAttempts <= 0 {
+		add("integrations.reviewer_sync.max_attempts: must be positive, got %d", sync.MaxAttempts)
+	}
+
+	if c.Integration.Deliveries.Retention < 0 {
+		add("integrations.deliveries.retention: must not be negative, got %s", c.Integration.Deliveries.Retention)
+	}
+	if c.Integration.Deliveries.PurgeInterval <= 0 {
+		add("integrations.deliveries.purge_interval: must be positive, got %s", c.Integration.Deliveries.PurgeInterval)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r RateLimitRule) validate() error {
+	if r.RPS < 0 {
+		return fmt.Errorf("rps must not be negative, got %v", r.RPS)
+	}
+	if r.RPS > 0 && r.Burst <= 0 {
+		return fmt.Errorf("burst must be positive, got %d", r.Burst)
+	}
+	return nil
+}
+
+func validIPOrCIDR(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	return net.ParseIP(value) != nil
+}
 
-	return &cfg
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
 }
 
-func fetchConfigPath() string {
+func FetchConfigPath() string {
 	var res string
 
 	flag.StringVar(&res, "config", "", "path to config file")
 	flag.Parse()
 
 	if res == "" {
-		res = ""
+		res = os.Getenv("CONFIG_PATH")
 	}
 
 	return res
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "xxxxx"
+
+func (c Config) Redacted() Config {
+	if c.Postgre.Password != "" {
+		c.Postgre.Password = redacted
+	}
+	if c.JWT.Secret != "" {
+		c.JWT.Secret = redacted
+	}
+	if c.OIDC.ClientSecret != "" {
+		c.OIDC.ClientSecret = redacted
+	}
+	if c.Integration.GitHub.WebhookSecret != "" {
+		c.Integration.GitHub.WebhookSecret = redacted
+	}
+	if c.Integration.GitLab.WebhookToken != "" {
+		c.Integration.GitLab.WebhookToken = redacted
+	}
+	if c.Integration.GitHub.APIToken != "" {
+		c.Integration.GitHub.APIToken = redacted
+	}
+	if c.Integration.GitLab.APIToken != "" {
+		c.Integration.GitLab.APIToken = redacted
+	}
+	if c.Postgre.DSN != "" {
+		c.Postgre.DSN = redactDSN(c.Postgre.DSN)
+	}
+
+	return c
+}
+
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return redacted
+	}
+
+	query := u.Query()
+	if query.Has("password") {
+		query.Set("password", redacted)
+		u.RawQuery = query.Encode()
+	}
+
+	return u.Redacted()
+}
+
+func Print(w io.Writer, cfg *Config) error {
+	out, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+func (c *Config) WithReloadable(next *Config) (*Config, []string) {
+	merged := *c
+
+	merged.Reviewers = next.Reviewers
+	merged.Log.Level = next.Log.Level
+	merged.JWT.AccessTokenTTL = next.JWT.AccessTokenTTL
+	merged.JWT.RefreshTokenTTL = next.JWT.RefreshTokenTTL
+	merged.RateLimit = next.RateLimit
+
+	return &merged, diffFields(reflect.ValueOf(merged), reflect.ValueOf(*next), "")
+}
+
+func diffFields(a, b reflect.Value, prefix string) []string {
+	if a.Kind() != reflect.Struct {
+		if reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	var changed []string
+	for i := 0; i < a.NumField(); i++ {
+		name := strings.Split(a.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		changed = append(changed, diffFields(a.Field(i), b.Field(i), name)...)
+	}
+
+	return changed
+}
@@ -6,5 +6,18 @@ var (
 	NOT_ASSIGNED = "NOT_ASSIGNED"
 	NO_CANDIDATE = "NO_CANDIDATE"
 	PR_MERGED    = "PR_MERGED"
+	PR_CLOSED    = "PR_CLOSED"
 	NOT_FOUND    = "NOT_FOUND"
+
+	VALIDATION_ERROR   = "VALIDATION_ERROR"
+	UNAUTHORIZED       = "UNAUTHORIZED"
+	FORBIDDEN          = "FORBIDDEN"
+	METHOD_NOT_ALLOWED = "METHOD_NOT_ALLOWED"
+	INTERNAL_ERROR     = "INTERNAL_ERROR"
+	PAYLOAD_TOO_LARGE  = "PAYLOAD_TOO_LARGE"
+	REQUEST_TIMEOUT    = "REQUEST_TIMEOUT"
+	RATE_LIMITED       = "RATE_LIMITED"
+
+	IDEMPOTENCY_KEY_REUSED  = "IDEMPOTENCY_KEY_REUSED"
+	IDEMPOTENCY_IN_PROGRESS = "IDEMPOTENCY_IN_PROGRESS"
 )
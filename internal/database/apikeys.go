@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const apiKeyColumns = `id, name, service_account, prefix, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+const qualifiedAPIKeyColumns = `api_keys.id, api_keys.name, api_keys.service_account, api_keys.prefix, api_keys.scopes,
+	api_keys.created_by, api_keys.created_at, api_keys.expires_at, api_keys.last_used_at, api_keys.revoked_at`
+
+func scanAPIKey(row pgx.Row) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.ServiceAccount, &key.Prefix, &key.Scopes,
+		&key.CreatedBy, &key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+	return key, err
+}
+
+func (db *Database) CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	tx, err := db.conn(ctx).Begin(ctx)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// аккаунт создаётся как сервисный; существующий пользователь подходит, только если он
+	// сам был создан как сервисный аккаунт
+	tag, err := tx.Exec(
+		ctx,
+		"INSERT INTO users (id, name) VALUES ($1, $1) ON CONFLICT (id) DO NOTHING",
+		key.ServiceAccount,
+	)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("ошибка создания сервисного аккаунта: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		_, err = tx.Exec(ctx, "INSERT INTO service_accounts (user_id) VALUES ($1)", key.ServiceAccount)
+		if err != nil {
+			return models.APIKey{}, fmt.Errorf("ошибка создания сервисного аккаунта: %w", err)
+		}
+	}
+
+	var admin bool
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE service_accounts SET is_admin = COALESCE($2, is_admin)
+		 WHERE user_id = $1
+		 RETURNING is_admin`,
+		key.ServiceAccount, key.ServiceAccountAdmin,
+	).Scan(&admin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, ErrNotServiceAccount
+		}
+		return models.APIKey{}, fmt.Errorf("ошибка проверки сервисного аккаунта: %w", err)
+	}
+
+	var expiresAt *time.Time
+	if key.ExpiresAt != nil {
+		utc := key.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	created, err := scanAPIKey(tx.QueryRow(
+		ctx,
+		`INSERT INTO api_keys (name, service_account, prefix, key_hash, scopes, created_by, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING `+apiKeyColumns,
+		key.Name, key.ServiceAccount, key.Prefix, key.Hash, key.Scopes, key.CreatedBy, expiresAt,
+	))
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("ошибка сохранения API-ключа: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.APIKey{}, fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+
+	created.ServiceAccountAdmin = &admin
+	return created, nil
+}
+
+func (db *Database) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys := make([]models.APIKey, 0)
+
+	err := db.ExecuteQuery(
+		ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at, id`,
+		nil,
+		func(rows pgx.Rows) error {
+			key, err := scanAPIKey(rows)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (db *Database) GetAPIKey(ctx context.Context, id string) (models.APIKey, error) {
+	key, err := scanAPIKey(db.conn(ctx).QueryRow(
+		ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 FOR UPDATE`,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, ErrNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("ошибка получения API-ключа: %w", err)
+	}
+
+	return key, nil
+}
+
+func (db *Database) RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error) {
+	key, err := scanAPIKey(db.conn(ctx).QueryRow(
+		ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		 WHERE id = $1
+		 RETURNING `+apiKeyColumns,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, ErrNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("ошибка отзыва API-ключа: %w", err)
+	}
+
+	return key, nil
+}
+
+// AuthenticateAPIKey находит действующий ключ сервисного аккаунта и возвращает его вместе с ролью аккаунта.
+func (db *Database) AuthenticateAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	var (
+		key   models.APIKey
+		admin bool
+	)
+	err := db.conn(ctx).QueryRow(
+		ctx,
+		`UPDATE api_keys SET last_used_at = NOW()
+		 FROM service_accounts sa
+		 WHERE sa.user_id = api_keys.service_account
+		   AND api_keys.key_hash = $1
+		   AND api_keys.revoked_at IS NULL
+		   AND (api_keys.expires_at IS NULL OR api_keys.expires_at > NOW())
+		 RETURNING `+qualifiedAPIKeyColumns+`, sa.is_admin`,
+		hash,
+	).Scan(&key.ID, &key.Name, &key.ServiceAccount, &key.Prefix, &key.Scopes,
+		&key.CreatedBy, &key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &admin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, ErrNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("ошибка проверки API-ключа: %w", err)
+	}
+
+	key.ServiceAccountAdmin = &admin
+	return key, nil
+}
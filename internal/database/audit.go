@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *Database) WriteAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	_, err := db.conn(ctx).Exec(
+		ctx,
+		`INSERT INTO audit_log (actor, api_key_id, action, target, request_id, before, after)
+		 VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)`,
+		entry.Actor, entry.APIKeyID, entry.Action, entry.Target, entry.RequestID,
+		nullableJSON(entry.Before), nullableJSON(entry.After),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка записи в журнал аудита: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.Actor != "" {
+		where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		where("action = ?", filter.Action)
+	}
+	if filter.Target != "" {
+		where("target = ?", filter.Target)
+	}
+	if filter.From != nil {
+		where("occurred_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		where("occurred_at < ?", filter.To.UTC())
+	}
+	if filter.Cursor > 0 {
+		where("id < ?", filter.Cursor)
+	}
+
+	query := `SELECT id, occurred_at, actor, COALESCE(api_key_id, ''), action, target, request_id, before, after FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += " ORDER BY id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	entries := make([]models.AuditEntry, 0, filter.Limit)
+	err := db.ExecuteQuery(ctx, query, args, func(rows pgx.Rows) error {
+		var entry models.AuditEntry
+		if err := rows.Scan(&entry.ID, &entry.OccurredAt, &entry.Actor, &entry.APIKeyID, &entry.Action,
+			&entry.Target, &entry.RequestID, &entry.Before, &entry.After); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (db *Database) PurgeAuditLog(ctx context.Context, olderThan time.Time) (int64, error) {
+	tag, err := db.conn(ctx).Exec(ctx, "DELETE FROM audit_log WHERE occurred_at < $1", olderThan.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки журнала аудита: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func nullableJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Parnishkaspb/avito/internal/codeowners"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *Database) SetCodeowners(ctx context.Context, c models.Codeowners) (models.Codeowners, error) {
+	teamID, exists, err := db.ReturnTeamID(ctx, c.TeamName)
+	if err != nil {
+		return models.Codeowners{}, err
+	}
+	if !exists {
+		return models.Codeowners{}, ErrNotFound
+	}
+
+	err = db.conn(ctx).QueryRow(
+		ctx,
+		`INSERT INTO codeowners (team_id, repository, content) VALUES ($1, $2, $3)
+		 ON CONFLICT (team_id, repository) DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+		 RETURNING updated_at`,
+		teamID, c.Repository, c.Content,
+	).Scan(&c.UpdatedAt)
+	if err != nil {
+		return models.Codeowners{}, fmt.Errorf("ошибка сохранения CODEOWNERS: %w", err)
+	}
+
+	return c, nil
+}
+
+func (db *Database) GetCodeowners(ctx context.Context, teamName, repository string) (models.Codeowners, error) {
+	c := models.Codeowners{TeamName: teamName, Repository: repository}
+	err := db.conn(ctx).QueryRow(
+		ctx,
+		`SELECT c.content, c.updated_at
+		 FROM codeowners c
+		 INNER JOIN teams t ON t.id = c.team_id
+		 WHERE t.name = $1 AND c.repository = $2`,
+		teamName, repository,
+	).Scan(&c.Content, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Codeowners{}, ErrNotFound
+		}
+		return models.Codeowners{}, fmt.Errorf("ошибка получения CODEOWNERS: %w", err)
+	}
+
+	return c, nil
+}
+
+func (db *Database) DeleteCodeowners(ctx context.Context, teamName, repository string) (models.Codeowners, error) {
+	c := models.Codeowners{TeamName: teamName, Repository: repository}
+	err := db.conn(ctx).QueryRow(
+		ctx,
+		`DELETE FROM codeowners c
+		 USING teams t
+		 WHERE t.id = c.team_id AND t.name = $1 AND c.repository = $2
+		 RETURNING c.content, c.updated_at`,
+		teamName, repository,
+	).Scan(&c.Content, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Codeowners{}, ErrNotFound
+		}
+		return models.Codeowners{}, fmt.Errorf("ошибка удаления CODEOWNERS: %w", err)
+	}
+
+	return c, nil
+}
+
+// codeOwnersForPR возвращает активных пользователей, владеющих изменёнными в PR путями, по
+// CODEOWNERS команды автора для репозитория PR. Автор в список не попадает.
+func codeOwnersForPR(ctx context.Context, q querier, req models.PullRequestCreateRequest) ([]string, error) {
+	repository := req.RepositoryName()
+	if repository == "" || len(req.ChangedFiles) == 0 {
+		return nil, nil
+	}
+
+	var content string
+	err := q.QueryRow(
+		ctx,
+		`SELECT c.content
+		 FROM codeowners c
+		 INNER JOIN team_members tm ON tm.team_id = c.team_id
+		 WHERE tm.user_id = $1 AND c.repository = $2
+		 ORDER BY c.team_id
+		 LIMIT 1`,
+		req.AuthorID, repository,
+	).Scan(&content)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка получения CODEOWNERS: %w", err)
+	}
+
+	file, err := codeowners.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора CODEOWNERS: %w", err)
+	}
+
+	return resolveCodeOwners(ctx, q, models.PullRequestProvider(repository), file.OwnersOf(req.ChangedFiles), req.AuthorID)
+}
+
+// resolveCodeOwners сопоставляет владельцев из CODEOWNERS пользователям сервиса: для репозиториев
+// интеграций — через identity_mappings, иначе @login считается id пользователя. Команды (@org/team)
+// и email пропускаются.
+func resolveCodeOwners(ctx context.Context, q querier, provider string, owners []string, authorID string) ([]string, error) {
+	var logins []string
+	for _, owner := range owners {
+		login, ok := strings.CutPrefix(owner, "@")
+		if !ok || strings.Contains(login, "/") {
+			continue
+		}
+		if provider != "" {
+			login = strings.ToLower(login)
+		}
+		logins = append(logins, login)
+	}
+	if len(logins) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT u.id FROM users u WHERE u.id = ANY($1) AND u.is_active = TRUE AND u.id <> $2`
+	args := []any{logins, authorID}
+	if provider != "" {
+		query = `SELECT u.id
+		 FROM identity_mappings im
+		 INNER JOIN users u ON u.id = im.user_id
+		 WHERE im.external_login = ANY($1) AND u.is_active = TRUE AND u.id <> $2 AND im.provider = $3`
+		args = append(args, provider)
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования: %w", err)
+		}
+		if !slices.Contains(userIDs, id) {
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// pickPreferredReviewers сначала выбирает из владельцев кода, а недостающих добирает из команды;
+// стратегия выбора применяется к обоим спискам.
+func pickPreferredReviewers(ctx context.Context, q querier, owners, teamMates []string, n int, strategy string) ([]string, error) {
+	reviewers, err := pickReviewers(ctx, q, owners, n, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := slices.DeleteFunc(slices.Clone(teamMates), func(id string) bool {
+		return slices.Contains(reviewers, id)
+	})
+
+	more, err := pickReviewers(ctx, q, rest, n-len(reviewers), strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(reviewers, more...), nil
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const uniqueViolationCode = "23505"
+
+var (
+	ErrNotFound    = errors.New("запись не найдена")
+	ErrPRExists    = errors.New("PR с таким id уже существует")
+	ErrPRMerged    = errors.New("PR уже слит")
+	ErrPRClosed    = errors.New("PR закрыт")
+	ErrNotAssigned = errors.New("пользователь не назначен ревьюером PR")
+	ErrNoCandidate = errors.New("нет доступных кандидатов для переназначения")
+
+	ErrNotServiceAccount = errors.New("пользователь не является сервисным аккаунтом")
+	ErrUserInactive      = errors.New("пользователь деактивирован")
+	ErrExternalIDTaken   = errors.New("внешний id уже сопоставлен другому логину")
+	ErrOIDCSubjectTaken  = errors.New("oidc_subject уже привязан к другому пользователю")
+
+	ErrIdempotencyInProgress = errors.New("ключ идемпотентности занят другим запросом")
+
+	ErrNotConnected      = errors.New("нет подключения к БД")
+	ErrMigrationsPending = errors.New("не применены миграции")
+)
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
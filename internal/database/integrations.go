@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const identityMappingColumns = `provider, external_login, external_id, user_id, created_at`
+
+func scanIdentityMapping(row pgx.Row) (models.IdentityMapping, error) {
+	var m models.IdentityMapping
+	var externalID *string
+	err := row.Scan(&m.Provider, &m.Login, &externalID, &m.UserID, &m.CreatedAt)
+	if externalID != nil {
+		m.ExternalID = *externalID
+	}
+	return m, err
+}
+
+// ResolveIdentity ищет пользователя по логину или неизменяемому id во внешней системе; если
+// известны оба, а у сопоставления логина id ещё не записан, он запоминается для событий без логина.
+func (db *Database) ResolveIdentity(ctx context.Context, provider, login, externalID string) (string, error) {
+	login = strings.ToLower(login)
+
+	if login != "" && externalID != "" {
+		_, err := db.conn(ctx).Exec(
+			ctx,
+			`UPDATE identity_mappings SET external_id = $3
+			 WHERE provider = $1 AND external_login = $2 AND external_id IS NULL
+			   AND NOT EXISTS (SELECT 1 FROM identity_mappings WHERE provider = $1 AND external_id = $3)`,
+			provider, login, externalID,
+		)
+		if err != nil {
+			return "", fmt.Errorf("ошибка сохранения внешнего id: %w", err)
+		}
+	}
+
+	var userID string
+	err := db.conn(ctx).QueryRow(
+		ctx,
+		`SELECT user_id FROM identity_mappings
+		 WHERE provider = $1 AND (external_login = $2 OR external_id = NULLIF($3, ''))
+		 ORDER BY external_id = NULLIF($3, '') IS TRUE DESC
+		 LIMIT 1`,
+		provider, login, externalID,
+	).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("ошибка поиска сопоставления: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (db *Database) SetIdentityMapping(ctx context.Context, m models.IdentityMapping) (models.IdentityMapping, error) {
+	exists, err := db.CheckUser(ctx, m.UserID)
+	if err != nil {
+		return models.IdentityMapping{}, err
+	}
+	if !exists {
+		return models.IdentityMapping{}, ErrNotFound
+	}
+
+	saved, err := scanIdentityMapping(db.conn(ctx).QueryRow(
+		ctx,
+		`INSERT INTO identity_mappings (provider, external_login, external_id, user_id)
+		 VALUES ($1, $2, NULLIF($3, ''), $4)
+		 ON CONFLICT (provider, external_login) DO UPDATE
+		 SET user_id = EXCLUDED.user_id, external_id = COALESCE(EXCLUDED.external_id, identity_mappings.external_id)
+		 RETURNING `+identityMappingColumns,
+		m.Provider, strings.ToLower(m.Login), m.ExternalID, m.UserID,
+	))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.IdentityMapping{}, ErrExternalIDTaken
+		}
+		return models.IdentityMapping{}, fmt.Errorf("ошибка сохранения сопоставления: %w", err)
+	}
+
+	return saved, nil
+}
+
+func (db *Database) ListIdentityMappings(ctx context.Context, provider string) ([]models.IdentityMapping, error) {
+	query := `SELECT ` + identityMappingColumns + ` FROM identity_mappings`
+	var args []any
+	if provider != "" {
+		query += " WHERE provider = $1"
+		args = append(args, provider)
+	}
+	query += " ORDER BY provider, external_login"
+
+	mappings := make([]models.IdentityMapping, 0)
+	err := db.ExecuteQuery(ctx, query, args, func(rows pgx.Rows) error {
+		m, err := scanIdentityMapping(rows)
+		if err != nil {
+			return err
+		}
+		mappings = append(mappings, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
+}
+
+func (db *Database) DeleteIdentityMapping(ctx context.Context, provider, login string) (models.IdentityMapping, error) {
+	m, err := scanIdentityMapping(db.conn(ctx).QueryRow(
+		ctx,
+		`DELETE FROM identity_mappings WHERE provider = $1 AND external_login = $2 RETURNING `+identityMappingColumns,
+		provider, strings.ToLower(login),
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.IdentityMapping{}, ErrNotFound
+		}
+		return models.IdentityMapping{}, fmt.Errorf("ошибка удаления сопоставления: %w", err)
+	}
+
+	return m, nil
+}
+
+// ListIdentityLogins возвращает логины пользователей в code host; без сопоставления пользователь
+// в ответ не попадает, при нескольких логинах берётся первый по алфавиту.
+func (db *Database) ListIdentityLogins(ctx context.Context, provider string, userIDs []string) (map[string]string, error) {
+	logins := make(map[string]string, len(userIDs))
+
+	err := db.ExecuteQuery(
+		ctx,
+		`SELECT user_id, MIN(external_login)
+		 FROM identity_mappings
+		 WHERE provider = $1 AND user_id = ANY($2)
+		 GROUP BY user_id`,
+		[]any{provider, userIDs},
+		func(rows pgx.Rows) error {
+			var userID, login string
+			if err := rows.Scan(&userID, &login); err != nil {
+				return err
+			}
+			logins[userID] = login
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return logins, nil
+}
+
+// ReserveIntegrationDelivery возвращает false, если доставка с таким id уже была обработана.
+func (db *Database) ReserveIntegrationDelivery(ctx context.Context, provider, deliveryID string) (bool, error) {
+	tag, err := db.conn(ctx).Exec(
+		ctx,
+		"INSERT INTO integration_deliveries (provider, delivery_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		provider, deliveryID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("ошибка записи доставки: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (db *Database) ReleaseIntegrationDelivery(ctx context.Context, provider, deliveryID string) error {
+	_, err := db.conn(ctx).Exec(
+		ctx,
+		"DELETE FROM integration_deliveries WHERE provider = $1 AND delivery_id = $2",
+		provider, deliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления доставки: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) ClosePullRequest(ctx context.Context, prID string) (bool, error) {
+	return db.setPullRequestStatus(ctx, prID, "1", "3")
+}
+
+func (db *Database) ReopenPullRequest(ctx context.Context, prID string) (bool, error) {
+	return db.setPullRequestStatus(ctx, prID, "3", "1")
+}
+
+func (db *Database) setPullRequestStatus(ctx context.Context, prID, from, to string) (bool, error) {
+	var status string
+	err := db.conn(ctx).QueryRow(
+		ctx,
+		`WITH old AS (SELECT id, status FROM pull_requests WHERE id = $1 FOR UPDATE)
+		 UPDATE pull_requests pr SET status = CASE WHEN old.status = $2 THEN $3 ELSE old.status END
+		 FROM old WHERE pr.id = old.id
+		 RETURNING old.status`,
+		prID, from, to,
+	).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrNotFound
+		}
+		return false, fmt.Errorf("ошибка смены статуса PR: %w", err)
+	}
+
+	switch status {
+	case from:
+		return true, nil
+	case to:
+		return false, nil
+	case "2":
+		return false, ErrPRMerged
+	default:
+		return false, fmt.Errorf("неожиданный статус PR %q", status)
+	}
+}
+
+// PurgeIntegrationDeliveries удаляет id доставок, полученных раньше olderThan.
+func (db *Database) PurgeIntegrationDeliveries(ctx context.Context, olderThan time.Time) (int64, error) {
+	tag, err := db.conn(ctx).Exec(ctx, "DELETE FROM integration_deliveries WHERE received_at < $1", olderThan.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки доставок: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -2,32 +2,76 @@ package database
 
 import (
 	"context"
+	"time"
+
 	"github.com/Parnishkaspb/avito/internal/models"
 	"github.com/jackc/pgx/v5"
 )
 
 type DB interface {
 	RunDatabase(ctx context.Context) error
+	Ping(ctx context.Context) error
+	CheckMigrations(ctx context.Context) error
 	CheckTeam(ctx context.Context, teamName string) (bool, error)
 	CreateTeam(ctx context.Context, teamAdd models.RequestTeamAddResponse) (bool, error)
 	GetTeam(ctx context.Context, teamName string) (bool, error)
 	CheckRoleUser(ctx context.Context, userID string) (bool, error)
+	CheckOrgAdmin(ctx context.Context, userID string) (bool, error)
+	BootstrapOrgAdmin(ctx context.Context, userID, name string) error
 	ReturnTeamID(ctx context.Context, teamName string) (string, bool, error)
 	ReturnTeamMembersByTeamID(ctx context.Context, teamID string) ([]models.RequestMembers, error)
 	CheckExists(ctx context.Context, table, id string) (bool, error)
 	CheckUser(ctx context.Context, userID string) (bool, error)
 	CheckPR(ctx context.Context, prID string) (bool, error)
 	ReturnTeamMembersByUserID(ctx context.Context, userID string) ([]string, error)
-	CreatePullRequest(ctx context.Context, id, name, authorID string) (models.PullRequestShort, error)
+	CreatePullRequestWithReviewers(ctx context.Context, req models.PullRequestCreateRequest, policy models.ReviewerPolicy) (models.PullRequestResponse, error)
 	GetUser(ctx context.Context, userID string) (models.UserActiveResponse, error)
 	UpdateActive(ctx context.Context, userID string, isActive bool) (bool, error)
 	ReturnUserReviewByUserID(ctx context.Context, userID string) ([]models.PullRequestShort, error)
-	CreatePullRequestAssignedReview(ctx context.Context, prID string, reviewerIDs []string) (bool, error)
 	ExecuteQuery(ctx context.Context, query string, args []interface{}, processRow func(rows pgx.Rows) error) error
-	MergePullRequest(ctx context.Context, prID string) (models.PullRequest, error)
-	ReassignPullRequest(ctx context.Context, prID, oldReviewerID, newReviewID string) error
+	MergePullRequest(ctx context.Context, prID string) (models.PullRequest, bool, error)
+	ReassignPullRequest(ctx context.Context, prID, oldReviewerID string, policy models.ReviewerPolicy) (string, error)
 	CheckStatusPR(ctx context.Context, prID string) (bool, error)
 	GetAvailableTeamMatesForPR(ctx context.Context, prID string) ([]string, error)
 	PullRequestFullInformation(ctx context.Context, prID string) (models.PullRequestResponse, error)
-	GetTeamMetrics(ctx context.Context) ([]models.TeamMetrics, int, int, error)
+	GetTeamMetrics(ctx context.Context, scopeUserID string) ([]models.TeamMetrics, int, int, error)
+	GetOpenReviewLoad(ctx context.Context) (map[string]int, error)
+	ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, key, scope string) error
+	CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	GetAPIKey(ctx context.Context, id string) (models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error)
+	AuthenticateAPIKey(ctx context.Context, hash string) (models.APIKey, error)
+	ResolveOIDCUser(ctx context.Context, identity models.OIDCIdentity, matchBy string, autoProvision bool) (models.User, error)
+	GetUserIdentity(ctx context.Context, userID string) (models.UserIdentity, error)
+	SetUserIdentity(ctx context.Context, req models.UserIdentityRequest) (models.UserIdentity, error)
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	WriteAuditEntry(ctx context.Context, entry models.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	PurgeAuditLog(ctx context.Context, olderThan time.Time) (int64, error)
+	CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id string) (models.WebhookSubscription, error)
+	ListWebhookDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, id int64) (models.WebhookDelivery, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingWebhook, error)
+	RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt models.WebhookAttempt) error
+	ResolveIdentity(ctx context.Context, provider, login, externalID string) (string, error)
+	SetIdentityMapping(ctx context.Context, m models.IdentityMapping) (models.IdentityMapping, error)
+	ListIdentityMappings(ctx context.Context, provider string) ([]models.IdentityMapping, error)
+	DeleteIdentityMapping(ctx context.Context, provider, login string) (models.IdentityMapping, error)
+	ReserveIntegrationDelivery(ctx context.Context, provider, deliveryID string) (bool, error)
+	ReleaseIntegrationDelivery(ctx context.Context, provider, deliveryID string) error
+	ClosePullRequest(ctx context.Context, prID string) (bool, error)
+	ReopenPullRequest(ctx context.Context, prID string) (bool, error)
+	ListIdentityLogins(ctx context.Context, provider string, userIDs []string) (map[string]string, error)
+	SetTeamReviewerSync(ctx context.Context, teamName string, enabled bool) (models.TeamReviewerSync, error)
+	GetReviewerSync(ctx context.Context, prID string) (models.ReviewerSync, error)
+	ClaimReviewerSyncs(ctx context.Context, limit int, lease time.Duration) ([]models.PendingReviewerSync, error)
+	RecordReviewerSync(ctx context.Context, prID string, revision int, attempt models.ReviewerSyncAttempt) error
+	SetCodeowners(ctx context.Context, c models.Codeowners) (models.Codeowners, error)
+	GetCodeowners(ctx context.Context, teamName, repository string) (models.Codeowners, error)
+	DeleteCodeowners(ctx context.Context, teamName, repository string) (models.Codeowners, error)
 }
@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys (
+    key VARCHAR NOT NULL,
+    scope VARCHAR NOT NULL,
+    fingerprint VARCHAR NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    content_type VARCHAR NOT NULL DEFAULT '',
+    response_body BYTEA,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    -- пишется из time.Time приложения и сравнивается с NOW(), поэтому с часовым поясом
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key, scope)
+);
+
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys(expires_at);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN is_org_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_users_org_admin ON users(is_org_admin) WHERE is_org_admin = TRUE;
@@ -0,0 +1,24 @@
+CREATE TABLE api_keys (
+    id VARCHAR PRIMARY KEY DEFAULT gen_random_uuid()::VARCHAR,
+    name VARCHAR NOT NULL,
+    service_account VARCHAR NOT NULL,
+    prefix VARCHAR NOT NULL,
+    key_hash VARCHAR NOT NULL UNIQUE,
+    scopes VARCHAR[] NOT NULL,
+    created_by VARCHAR NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMP DEFAULT NULL,
+    last_used_at TIMESTAMP DEFAULT NULL,
+    revoked_at TIMESTAMP DEFAULT NULL,
+    FOREIGN KEY (service_account) REFERENCES users(id)
+);
+
+CREATE INDEX idx_api_keys_service_account ON api_keys(service_account);
+
+-- пользователи, созданные для API-ключей; ключ действует только от имени такого аккаунта
+-- и с его ролью (is_admin), а не от имени произвольного пользователя
+CREATE TABLE service_accounts (
+    user_id VARCHAR PRIMARY KEY,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
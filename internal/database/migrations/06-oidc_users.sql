@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN email VARCHAR;
+ALTER TABLE users ADD COLUMN oidc_subject VARCHAR;
+
+CREATE UNIQUE INDEX idx_users_oidc_subject ON users(oidc_subject);
+CREATE INDEX idx_users_email ON users(LOWER(email));
@@ -0,0 +1,26 @@
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    actor VARCHAR NOT NULL,
+    api_key_id VARCHAR,
+    action VARCHAR NOT NULL,
+    target VARCHAR NOT NULL,
+    request_id VARCHAR NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX idx_audit_log_occurred ON audit_log(occurred_at);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor, id);
+CREATE INDEX idx_audit_log_target ON audit_log(target, id);
+
+-- журнал только дополняется: записи нельзя менять, удалять можно только по retention
+CREATE FUNCTION audit_log_forbid_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_forbid_update();
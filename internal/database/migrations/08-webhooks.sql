@@ -0,0 +1,35 @@
+CREATE TABLE webhook_subscriptions (
+    id VARCHAR PRIMARY KEY DEFAULT gen_random_uuid()::VARCHAR,
+    url VARCHAR NOT NULL,
+    secret VARCHAR NOT NULL,
+    event_types VARCHAR[] NOT NULL,
+    created_by VARCHAR NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- outbox: событие пишется в той же транзакции, что и изменение данных
+CREATE TABLE webhook_events (
+    id VARCHAR PRIMARY KEY DEFAULT gen_random_uuid()::VARCHAR,
+    type VARCHAR NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR NOT NULL,
+    subscription_id VARCHAR NOT NULL,
+    status VARCHAR NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    last_attempt_at TIMESTAMP DEFAULT NULL,
+    last_status_code INT DEFAULT NULL,
+    last_error VARCHAR DEFAULT NULL,
+    delivered_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    FOREIGN KEY (event_id) REFERENCES webhook_events(id) ON DELETE CASCADE,
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, id);
@@ -0,0 +1,28 @@
+INSERT INTO pull_request_statuses(id, name) VALUES ('3', 'CLOSED');
+
+-- логин во внешней системе (GitHub, GitLab, ...) -> пользователь сервиса
+CREATE TABLE identity_mappings (
+    provider VARCHAR NOT NULL,
+    external_login VARCHAR NOT NULL,
+    -- неизменяемый числовой id во внешней системе: в хуке GitLab есть только author_id,
+    -- а логин автора известен лишь когда событие вызвал он сам
+    external_id VARCHAR,
+    user_id VARCHAR NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (provider, external_login),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_identity_mappings_user ON identity_mappings(user_id);
+CREATE UNIQUE INDEX idx_identity_mappings_external_id ON identity_mappings(provider, external_id);
+
+-- уже обработанные доставки вебхуков от code host, для идемпотентности повторов
+CREATE TABLE integration_deliveries (
+    provider VARCHAR NOT NULL,
+    delivery_id VARCHAR NOT NULL,
+    received_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (provider, delivery_id)
+);
+
+-- фоновая очистка integration_deliveries удаляет доставки по received_at
+CREATE INDEX idx_integration_deliveries_received_at ON integration_deliveries(received_at);
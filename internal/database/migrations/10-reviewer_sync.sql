@@ -0,0 +1,26 @@
+-- команды, для которых назначенные ревьюеры отправляются обратно в code host
+CREATE TABLE team_reviewer_sync (
+    team_id VARCHAR PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE
+);
+
+-- состояние синхронизации ревьюеров PR с code host; revision растёт при каждом
+-- назначении, чтобы результат устаревшей попытки не перезаписал новую
+CREATE TABLE reviewer_sync (
+    pull_request_id VARCHAR PRIMARY KEY,
+    provider VARCHAR NOT NULL,
+    status VARCHAR NOT NULL DEFAULT 'pending',
+    revision INTEGER NOT NULL DEFAULT 1,
+    removed_reviewers VARCHAR[] NOT NULL DEFAULT '{}',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    last_attempt_at TIMESTAMP,
+    last_error VARCHAR,
+    synced_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_reviewer_sync_pending ON reviewer_sync(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,12 @@
+-- файлы CODEOWNERS, зарегистрированные командой для репозитория; по ним при создании PR
+-- предпочитаются владельцы изменённых путей
+CREATE TABLE codeowners (
+    team_id VARCHAR NOT NULL,
+    repository VARCHAR NOT NULL,
+    content TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (team_id, repository),
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_codeowners_repository ON codeowners(repository);
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *Database) ResolveOIDCUser(ctx context.Context, identity models.OIDCIdentity, matchBy string, autoProvision bool) (models.User, error) {
+	tx, err := db.conn(ctx).Begin(ctx)
+	if err != nil {
+		return models.User{}, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	query := "SELECT id, COALESCE(name, ''), COALESCE(is_active, TRUE) FROM users WHERE oidc_subject = $1"
+	arg := identity.Subject
+	if matchBy == "email" {
+		query = "SELECT id, COALESCE(name, ''), COALESCE(is_active, TRUE) FROM users WHERE LOWER(email) = LOWER($1) ORDER BY id LIMIT 1"
+		arg = identity.Email
+	}
+
+	var user models.User
+	err = tx.QueryRow(ctx, query, arg).Scan(&user.ID, &user.Username, &user.IsActive)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		if !autoProvision {
+			return models.User{}, ErrNotFound
+		}
+
+		user.Username = identity.Name
+		if user.Username == "" {
+			user.Username = identity.Email
+		}
+		if user.Username == "" {
+			user.Username = identity.Subject
+		}
+		user.IsActive = true
+
+		err = tx.QueryRow(
+			ctx,
+			`INSERT INTO users (name, email, oidc_subject) VALUES ($1, NULLIF($2, ''), $3)
+			 ON CONFLICT (oidc_subject) DO UPDATE SET oidc_subject = EXCLUDED.oidc_subject
+			 RETURNING id`,
+			user.Username, identity.Email, identity.Subject,
+		).Scan(&user.ID)
+		if err != nil {
+			return models.User{}, fmt.Errorf("ошибка создания пользователя: %w", err)
+		}
+	case err != nil:
+		return models.User{}, fmt.Errorf("ошибка поиска пользователя: %w", err)
+	case !user.IsActive:
+		return models.User{}, ErrUserInactive
+	}
+
+	if autoProvision {
+		for _, team := range identity.Teams {
+			if err = addTeamMember(ctx, tx, team, user.ID); err != nil {
+				return models.User{}, err
+			}
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.User{}, fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+
+	return user, nil
+}
+
+func addTeamMember(ctx context.Context, q querier, teamName, userID string) error {
+	_, err := q.Exec(ctx, "INSERT INTO teams (name) VALUES ($1) ON CONFLICT (name) DO NOTHING", teamName)
+	if err != nil {
+		return fmt.Errorf("ошибка создания команды: %w", err)
+	}
+
+	_, err = q.Exec(
+		ctx,
+		`INSERT INTO team_members (team_id, user_id)
+		 SELECT t.id, $2 FROM teams t
+		 WHERE t.name = $1
+		   AND NOT EXISTS (SELECT 1 FROM team_members tm WHERE tm.team_id = t.id AND tm.user_id = $2)`,
+		teamName, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка добавления участника команды: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) GetUserIdentity(ctx context.Context, userID string) (models.UserIdentity, error) {
+	identity := models.UserIdentity{UserID: userID}
+	err := db.conn(ctx).QueryRow(
+		ctx,
+		"SELECT COALESCE(email, ''), COALESCE(oidc_subject, '') FROM users WHERE id = $1",
+		userID,
+	).Scan(&identity.Email, &identity.OIDCSubject)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.UserIdentity{}, ErrNotFound
+		}
+		return models.UserIdentity{}, fmt.Errorf("ошибка получения SSO-идентичности: %w", err)
+	}
+
+	return identity, nil
+}
+
+// SetUserIdentity привязывает существующего пользователя к SSO, чтобы ResolveOIDCUser находил его без автосоздания.
+func (db *Database) SetUserIdentity(ctx context.Context, req models.UserIdentityRequest) (models.UserIdentity, error) {
+	identity := models.UserIdentity{UserID: req.UserID}
+	err := db.conn(ctx).QueryRow(
+		ctx,
+		`UPDATE users
+		 SET email = NULLIF(COALESCE($2, email), ''), oidc_subject = NULLIF(COALESCE($3, oidc_subject), '')
+		 WHERE id = $1
+		 RETURNING COALESCE(email, ''), COALESCE(oidc_subject, '')`,
+		req.UserID, req.Email, req.OIDCSubject,
+	).Scan(&identity.Email, &identity.OIDCSubject)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.UserIdentity{}, ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return models.UserIdentity{}, ErrOIDCSubjectTaken
+		}
+		return models.UserIdentity{}, fmt.Errorf("ошибка обновления SSO-идентичности: %w", err)
+	}
+
+	return identity, nil
+}
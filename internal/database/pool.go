@@ -0,0 +1,68 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func buildDSN(cfg config.PostreSQLConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+
+	query := url.Values{}
+	if cfg.SSLMode != "" {
+		query.Set("sslmode", cfg.SSLMode)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.DB,
+		RawQuery: query.Encode(),
+	}
+
+	return dsn.String()
+}
+
+func buildPoolConfig(cfg config.PostreSQLConfig) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(buildDSN(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("некорректная конфигурация БД: %w", err)
+	}
+
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = cfg.MinConns
+	}
+	if poolConfig.MinConns > poolConfig.MaxConns {
+		return nil, fmt.Errorf("некорректная конфигурация БД: min_conns (%d) больше max_conns (%d)", poolConfig.MinConns, poolConfig.MaxConns)
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	params := poolConfig.ConnConfig.RuntimeParams
+	if cfg.ApplicationName != "" {
+		params["application_name"] = cfg.ApplicationName
+	}
+	if cfg.StatementTimeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+
+	return poolConfig, nil
+}
@@ -8,13 +8,30 @@ import (
 	"github.com/Parnishkaspb/avito/internal/config"
 	"github.com/Parnishkaspb/avito/internal/helper"
 	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/Parnishkaspb/avito/internal/tracing"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"log"
+	"log/slog"
 	"strings"
+	"sync"
 	"time"
 )
 
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// txConn — пул или транзакция: Begin на транзакции открывает savepoint.
+type txConn interface {
+	querier
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type txKey struct{}
+
 type Database struct {
 	User     string
 	Password string
@@ -23,9 +40,45 @@ type Database struct {
 	DB       string
 	SSLMode  string
 	Pool     *pgxpool.Pool
+	log      *slog.Logger
+
+	cfg config.PostreSQLConfig
+
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
-func New(databaseConfig config.PostreSQLConfig) *Database {
+var requiredTables = []string{
+	"users",
+	"teams",
+	"team_members",
+	"pull_request_statuses",
+	"pull_requests",
+	"pull_request_assigned_reviewers",
+	"idempotency_keys",
+	"api_keys",
+	"service_accounts",
+	"audit_log",
+	"webhook_subscriptions",
+	"webhook_events",
+	"webhook_deliveries",
+	"identity_mappings",
+	"integration_deliveries",
+	"team_reviewer_sync",
+	"reviewer_sync",
+	"codeowners",
+}
+
+var requiredColumns = [][2]string{
+	{"users", "is_org_admin"},
+	{"users", "oidc_subject"},
+}
+
+func New(databaseConfig config.PostreSQLConfig, log *slog.Logger) *Database {
+	if log == nil {
+		log = slog.Default()
+	}
+
 	return &Database{
 		User:     databaseConfig.User,
 		Password: databaseConfig.Password,
@@ -33,36 +86,159 @@ func New(databaseConfig config.PostreSQLConfig) *Database {
 		Port:     databaseConfig.Port,
 		DB:       databaseConfig.DB,
 		SSLMode:  databaseConfig.SSLMode,
+		log:      log.With(slog.String("component", "database")),
+		cfg:      databaseConfig,
+		ready:    make(chan struct{}),
 	}
 }
 
-func (db *Database) RunDatabase(ctx context.Context) error {
-	url := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		db.User, db.Password, db.Host, db.Port, db.DB, db.SSLMode,
-	)
+func (db *Database) Ready() <-chan struct{} {
+	return db.ready
+}
+
+func (db *Database) markReady() {
+	if db.ready == nil {
+		return
+	}
+	db.readyOnce.Do(func() { close(db.ready) })
+}
+
+func (db *Database) Ping(ctx context.Context) error {
+	if db.Pool == nil {
+		return ErrNotConnected
+	}
+
+	if err := db.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("БД не пингуется: %w", err)
+	}
+	return nil
+}
+
+func (db *Database) CheckMigrations(ctx context.Context) error {
+	if db.Pool == nil {
+		return ErrNotConnected
+	}
+
+	var missing []string
+	for _, table := range requiredTables {
+		var exists bool
+		if err := db.Pool.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil {
+			return fmt.Errorf("ошибка запроса: %w", err)
+		}
+		if !exists {
+			missing = append(missing, table)
+		}
+	}
+
+	for _, column := range requiredColumns {
+		var exists bool
+		err := db.Pool.QueryRow(
+			ctx,
+			`SELECT EXISTS(SELECT 1 FROM information_schema.columns
+			 WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2)`,
+			column[0], column[1],
+		).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("ошибка запроса: %w", err)
+		}
+		if !exists {
+			missing = append(missing, column[0]+"."+column[1])
+		}
+	}
 
-	pool, err := pgxpool.New(ctx, url)
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMigrationsPending, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (db *Database) Connect(ctx context.Context) error {
+	poolConfig, err := buildPoolConfig(db.cfg)
 	if err != nil {
-		return fmt.Errorf("проблемы с подключением к БД: %w", err)
+		return err
+	}
+	poolConfig.ConnConfig.Tracer = tracing.NewQueryTracer()
+
+	pool, err := db.connect(ctx, poolConfig)
+	if err != nil {
+		return err
 	}
 
 	db.Pool = pool
 
-	if err := pool.Ping(ctx); err != nil {
-		return fmt.Errorf("ошибка! БД не пингуется: %w", err)
+	db.log.InfoContext(ctx, "database connection established",
+		slog.String("host", poolConfig.ConnConfig.Host),
+		slog.Int("port", int(poolConfig.ConnConfig.Port)),
+		slog.String("db", poolConfig.ConnConfig.Database),
+		slog.Int("max_conns", int(poolConfig.MaxConns)),
+	)
+	return nil
+}
+
+func (db *Database) Close() {
+	if db.Pool != nil {
+		db.log.Info("closing database connection")
+		db.Pool.Close()
 	}
+}
 
-	log.Println("Соединение с БД успешно выполнено")
+func (db *Database) RunDatabase(ctx context.Context) error {
+	if err := db.Connect(ctx); err != nil {
+		return err
+	}
+	db.markReady()
 
 	<-ctx.Done()
-	log.Println("Закрываем соединение с БД")
-	pool.Close()
+	db.Close()
 	return nil
 }
 
+func (db *Database) connect(ctx context.Context, poolConfig *pgxpool.Config) (*pgxpool.Pool, error) {
+	backoff := db.cfg.ConnectBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	for attempt := 1; ; attempt++ {
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			return nil, fmt.Errorf("проблемы с подключением к БД: %w", err)
+		}
+
+		err = pool.Ping(ctx)
+		if err == nil {
+			return pool, nil
+		}
+		pool.Close()
+
+		if db.cfg.ConnectAttempts > 0 && attempt >= db.cfg.ConnectAttempts {
+			return nil, fmt.Errorf("ошибка! БД не пингуется после %d попыток: %w", attempt, err)
+		}
+
+		db.log.WarnContext(ctx, "database is not reachable, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.Any("error", err),
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if db.cfg.ConnectMaxBackoff > 0 && backoff > db.cfg.ConnectMaxBackoff {
+			backoff = db.cfg.ConnectMaxBackoff
+		}
+	}
+}
+
 func (db *Database) CheckTeam(ctx context.Context, teamName string) (bool, error) {
 	var exists bool
-	err := db.Pool.QueryRow(
+	err := db.conn(ctx).QueryRow(
 		ctx,
 		"SELECT EXISTS(SELECT 1 FROM teams WHERE name=$1)",
 		teamName,
@@ -78,7 +254,7 @@ func (db *Database) CheckTeam(ctx context.Context, teamName string) (bool, error
 func (db *Database) CreateTeam(ctx context.Context, teamAdd models.RequestTeamAddResponse) (bool, error) {
 	exists, err := db.CheckTeam(ctx, teamAdd.TeamName)
 	if err != nil {
-		log.Printf("проблемы с проверкой команды: %s", err)
+		db.log.ErrorContext(ctx, "team check failed", slog.String("team_name", teamAdd.TeamName), slog.Any("error", err))
 		return false, fmt.Errorf("ошибка запроса: %w", err)
 	}
 
@@ -86,7 +262,7 @@ func (db *Database) CreateTeam(ctx context.Context, teamAdd models.RequestTeamAd
 		return exists, nil
 	}
 
-	tx, err := db.Pool.Begin(ctx)
+	tx, err := db.conn(ctx).Begin(ctx)
 	if err != nil {
 		return false, fmt.Errorf("не удалось начать транзакцию: %w", err)
 	}
@@ -131,7 +307,7 @@ func (db *Database) CreateTeam(ctx context.Context, teamAdd models.RequestTeamAd
 func (db *Database) GetTeam(ctx context.Context, teamName string) (bool, error) {
 	exists, err := db.CheckTeam(ctx, teamName)
 	if err != nil {
-		log.Printf("проблемы с проверкой команды: %s", err)
+		db.log.ErrorContext(ctx, "team check failed", slog.String("team_name", teamName), slog.Any("error", err))
 		return false, fmt.Errorf("ошибка запроса: %w", err)
 	}
 
@@ -142,7 +318,7 @@ func (db *Database) CheckExists(ctx context.Context, table, id string) (bool, er
 	var exists bool
 	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id=$1)", table)
 
-	err := db.Pool.QueryRow(ctx, query, id).Scan(&exists)
+	err := db.conn(ctx).QueryRow(ctx, query, id).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("ошибка запроса: %w", err)
 	}
@@ -160,7 +336,7 @@ func (db *Database) CheckPR(ctx context.Context, prID string) (bool, error) {
 
 func (db *Database) GetUser(ctx context.Context, userID string) (models.UserActiveResponse, error) {
 	var user models.UserActiveResponse
-	err := db.Pool.QueryRow(
+	err := db.conn(ctx).QueryRow(
 		ctx,
 		`SELECT u.id as user_id, u.name as username, COALESCE(t.name, '') as team_name, u.is_active FROM users u 
     		LEFT JOIN team_members tm ON tm.user_id = u.id 
@@ -180,32 +356,92 @@ func (db *Database) GetUser(ctx context.Context, userID string) (models.UserActi
 }
 
 func (db *Database) UpdateActive(ctx context.Context, userID string, isActive bool) (bool, error) {
-	_, err := db.Pool.Exec(ctx, "UPDATE users SET is_active = $1 WHERE id = $2", isActive, userID)
+	tx, err := db.conn(ctx).Begin(ctx)
 	if err != nil {
+		return false, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var wasActive bool
+	err = tx.QueryRow(
+		ctx,
+		"UPDATE users u SET is_active = $1 FROM (SELECT id, COALESCE(is_active, TRUE) AS is_active FROM users WHERE id = $2 FOR UPDATE) old WHERE u.id = old.id RETURNING old.is_active",
+		isActive, userID,
+	).Scan(&wasActive)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return true, nil
+		}
 		return false, fmt.Errorf("ошибка обновления пользователя: %w", err)
 	}
 
+	if wasActive && !isActive {
+		if err = enqueueWebhookEvent(ctx, tx, models.EventUserDeactivated, models.UserDeactivatedEvent{UserID: userID}); err != nil {
+			return false, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+
 	return true, nil
 }
 
 func (db *Database) CheckRoleUser(ctx context.Context, userID string) (bool, error) {
 	var is_admin bool
-	err := db.Pool.QueryRow(
+	err := db.conn(ctx).QueryRow(
 		ctx,
 		"SELECT is_admin FROM team_members WHERE user_id = $1",
 		userID,
 	).Scan(&is_admin)
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
 		return false, fmt.Errorf("ошибка запроса: %w", err)
 	}
 
 	return is_admin, nil
 }
 
+func (db *Database) CheckOrgAdmin(ctx context.Context, userID string) (bool, error) {
+	var isOrgAdmin bool
+	err := db.conn(ctx).QueryRow(
+		ctx,
+		"SELECT is_org_admin FROM users WHERE id = $1",
+		userID,
+	).Scan(&isOrgAdmin)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("ошибка запроса: %w", err)
+	}
+
+	return isOrgAdmin, nil
+}
+
+func (db *Database) BootstrapOrgAdmin(ctx context.Context, userID, name string) error {
+	_, err := db.conn(ctx).Exec(
+		ctx,
+		`INSERT INTO users (id, name, is_org_admin) VALUES ($1, NULLIF($2, ''), TRUE)
+		 ON CONFLICT (id) DO UPDATE
+		 SET is_org_admin = TRUE, name = COALESCE(NULLIF($2, ''), users.name)`,
+		userID, name,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка назначения администратора: %w", err)
+	}
+
+	return nil
+}
+
 func (db *Database) ReturnTeamID(ctx context.Context, teamName string) (string, bool, error) {
 	var teamID string
-	err := db.Pool.QueryRow(
+	err := db.conn(ctx).QueryRow(
 		ctx,
 		"SELECT id FROM teams WHERE name = $1",
 		teamName,
@@ -221,8 +457,42 @@ func (db *Database) ReturnTeamID(ctx context.Context, teamName string) (string,
 	return teamID, true, nil
 }
 
+// conn возвращает транзакцию, открытую InTx, или пул, если метод вызван вне неё.
+func (db *Database) conn(ctx context.Context) txConn {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db.Pool
+}
+
+// InTx выполняет fn в одной транзакции: методы Database, вызванные с переданным в fn контекстом,
+// работают в ней. Ошибка fn откатывает все изменения.
+func (db *Database) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+
+	// после Commit откат ничего не делает; так транзакция не остаётся открытой и при панике в fn
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+
+	return nil
+}
+
 func (db *Database) ExecuteQuery(ctx context.Context, query string, args []interface{}, processRow func(rows pgx.Rows) error) error {
-	rows, err := db.Pool.Query(ctx, query, args...)
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("ошибка выполнения запроса: %w", err)
 	}
@@ -305,7 +575,11 @@ func (db *Database) ReturnUserReviewByUserID(ctx context.Context, userID string)
 }
 
 func (db *Database) ReturnTeamMembersByUserID(ctx context.Context, userID string) ([]string, error) {
-	rows, err := db.Pool.Query(
+	return teamMatesByUserID(ctx, db.conn(ctx), userID)
+}
+
+func teamMatesByUserID(ctx context.Context, q querier, userID string) ([]string, error) {
+	rows, err := q.Query(
 		ctx,
 		`SELECT DISTINCT u.id
 		FROM users u
@@ -342,12 +616,16 @@ func (db *Database) ReturnTeamMembersByUserID(ctx context.Context, userID string
 }
 
 func (db *Database) GetAvailableTeamMatesForPR(ctx context.Context, prID string) ([]string, error) {
-	rows, err := db.Pool.Query(
+	return availableTeamMatesForPR(ctx, db.conn(ctx), prID)
+}
+
+func availableTeamMatesForPR(ctx context.Context, q querier, prID string) ([]string, error) {
+	rows, err := q.Query(
 		ctx,
-		`SELECT u.id
+		`SELECT DISTINCT u.id
 		 FROM users u
 		 INNER JOIN team_members tm ON tm.user_id = u.id
-		 WHERE tm.team_id = (
+		 WHERE tm.team_id IN (
 		     SELECT tm2.team_id
 		     FROM team_members tm2
 		     INNER JOIN pull_requests pr ON pr.author_id = tm2.user_id
@@ -383,35 +661,157 @@ func (db *Database) GetAvailableTeamMatesForPR(ctx context.Context, prID string)
 	return userIDs, nil
 }
 
-func (db *Database) CreatePullRequest(ctx context.Context, id, name, authorID string) (models.PullRequestShort, error) {
-	var pr models.PullRequestShort
+func (db *Database) CreatePullRequestWithReviewers(ctx context.Context, req models.PullRequestCreateRequest, policy models.ReviewerPolicy) (models.PullRequestResponse, error) {
+	tx, err := db.conn(ctx).Begin(ctx)
+	if err != nil {
+		return models.PullRequestResponse{}, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	_, err := db.Pool.Exec(
+	var inTeam bool
+	err = tx.QueryRow(
+		ctx,
+		`SELECT EXISTS(SELECT 1 FROM users u
+		 INNER JOIN team_members tm ON tm.user_id = u.id
+		 WHERE u.id = $1)`,
+		req.AuthorID,
+	).Scan(&inTeam)
+	if err != nil {
+		return models.PullRequestResponse{}, fmt.Errorf("ошибка проверки автора: %w", err)
+	}
+
+	if !inTeam {
+		return models.PullRequestResponse{}, ErrNotFound
+	}
+
+	_, err = tx.Exec(
 		ctx,
 		`INSERT INTO pull_requests (id, name, author_id) VALUES ($1, $2, $3)`,
-		id, name, authorID,
+		req.PullRequestId, req.PullRequestName, req.AuthorID,
 	)
 	if err != nil {
-		return models.PullRequestShort{}, fmt.Errorf("ошибка создания PR: %w", err)
+		if isUniqueViolation(err) {
+			return models.PullRequestResponse{}, ErrPRExists
+		}
+		return models.PullRequestResponse{}, fmt.Errorf("ошибка создания PR: %w", err)
+	}
+
+	teamMates, err := teamMatesByUserID(ctx, tx, req.AuthorID)
+	if err != nil {
+		return models.PullRequestResponse{}, err
+	}
+
+	owners, err := codeOwnersForPR(ctx, tx, req)
+	if err != nil {
+		return models.PullRequestResponse{}, err
+	}
+
+	reviewers, err := pickPreferredReviewers(ctx, tx, owners, teamMates, policy.Count, policy.Strategy)
+	if err != nil {
+		return models.PullRequestResponse{}, err
 	}
 
-	err = db.Pool.QueryRow(
+	if err = insertAssignedReviewers(ctx, tx, req.PullRequestId, reviewers); err != nil {
+		return models.PullRequestResponse{}, err
+	}
+
+	pr := models.PullRequestResponse{AssignedReviewers: reviewers}
+	if pr.AssignedReviewers == nil {
+		pr.AssignedReviewers = []string{}
+	}
+
+	err = tx.QueryRow(
 		ctx,
 		`SELECT pr.id, pr.name, pr.author_id, prs.name AS status_name
-     FROM pull_requests pr
-     INNER JOIN pull_request_statuses prs ON pr.status = prs.id
-     WHERE pr.id = $1`,
-		id,
+		 FROM pull_requests pr
+		 INNER JOIN pull_request_statuses prs ON pr.status = prs.id
+		 WHERE pr.id = $1`,
+		req.PullRequestId,
 	).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status)
-
 	if err != nil {
-		return models.PullRequestShort{}, fmt.Errorf("ошибка вывода PR: %w", err)
+		return models.PullRequestResponse{}, fmt.Errorf("ошибка вывода PR: %w", err)
+	}
+
+	event := models.PullRequestEvent{
+		PullRequestID:     pr.PullRequestID,
+		PullRequestName:   pr.PullRequestName,
+		AuthorID:          pr.AuthorID,
+		Status:            pr.Status,
+		AssignedReviewers: pr.AssignedReviewers,
+	}
+	if err = enqueueWebhookEvent(ctx, tx, models.EventPullRequestCreated, event); err != nil {
+		return models.PullRequestResponse{}, err
+	}
+	if len(reviewers) > 0 {
+		if err = enqueueWebhookEvent(ctx, tx, models.EventPullRequestReviewersAssigned, event); err != nil {
+			return models.PullRequestResponse{}, err
+		}
+		if err = enqueueReviewerSync(ctx, tx, pr.PullRequestID, nil); err != nil {
+			return models.PullRequestResponse{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.PullRequestResponse{}, fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
 	}
 
 	return pr, nil
 }
 
-func (db *Database) CreatePullRequestAssignedReview(ctx context.Context, prID string, reviewerIDs []string) (bool, error) {
+func pickReviewers(ctx context.Context, q querier, candidates []string, n int, strategy string) ([]string, error) {
+	if n <= 0 || len(candidates) == 0 {
+		return nil, nil
+	}
+
+	if strategy != models.ReviewerStrategyLeastLoaded {
+		return helper.PickRandomTeamMates(candidates, n), nil
+	}
+
+	load, err := openReviewLoad(ctx, q, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	return helper.PickLeastLoadedTeamMates(candidates, load, n), nil
+}
+
+func openReviewLoad(ctx context.Context, q querier, userIDs []string) (map[string]int, error) {
+	rows, err := q.Query(
+		ctx,
+		`SELECT prar.user_id, COUNT(*)
+		 FROM pull_request_assigned_reviewers prar
+		 INNER JOIN pull_requests pr ON pr.id = prar.pull_request_id
+		 WHERE pr.status = '1' AND prar.user_id = ANY($1)
+		 GROUP BY prar.user_id`,
+		userIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %w", err)
+	}
+	defer rows.Close()
+
+	load := make(map[string]int, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования: %w", err)
+		}
+		load[userID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	return load, nil
+}
+
+func insertAssignedReviewers(ctx context.Context, q querier, prID string, reviewerIDs []string) error {
+	if len(reviewerIDs) == 0 {
+		return nil
+	}
+
 	values := make([]string, 0, len(reviewerIDs))
 	args := make([]interface{}, 0, len(reviewerIDs)*2)
 
@@ -427,31 +827,22 @@ func (db *Database) CreatePullRequestAssignedReview(ctx context.Context, prID st
 		strings.Join(values, ","),
 	)
 
-	_, err := db.Pool.Exec(ctx, query, args...)
-	if err != nil {
-		return false, fmt.Errorf("ошибка создания PR: %w", err)
+	if _, err := q.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("ошибка назначения ревьюеров: %w", err)
 	}
 
-	return true, nil
+	return nil
 }
 
-func (db *Database) MergePullRequest(ctx context.Context, prID string) (models.PullRequest, error) {
+func (db *Database) MergePullRequest(ctx context.Context, prID string) (models.PullRequest, bool, error) {
 	var pr models.PullRequest
+	var merged bool
 
-	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := db.conn(ctx).Begin(ctx)
 	if err != nil {
-		return pr, fmt.Errorf("не удалось начать транзакцию: %w", err)
+		return pr, false, fmt.Errorf("не удалось начать транзакцию: %w", err)
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback(ctx)
-			panic(p)
-		} else if err != nil {
-			tx.Rollback(ctx)
-		} else {
-			tx.Commit(ctx)
-		}
-	}()
+	defer func() { _ = tx.Rollback(ctx) }()
 
 	var dbStatus string
 	var mergedAt *time.Time
@@ -465,7 +856,14 @@ func (db *Database) MergePullRequest(ctx context.Context, prID string) (models.P
 		prID,
 	).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &dbStatus, &mergedAt) // сканируем в string
 	if err != nil {
-		return pr, fmt.Errorf("ошибка при получении PR: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pr, false, ErrNotFound
+		}
+		return pr, false, fmt.Errorf("ошибка при получении PR: %w", err)
+	}
+
+	if dbStatus == "3" {
+		return pr, false, ErrPRClosed
 	}
 
 	if dbStatus == "1" {
@@ -475,9 +873,9 @@ func (db *Database) MergePullRequest(ctx context.Context, prID string) (models.P
 			prID,
 		)
 		if err != nil {
-			return pr, fmt.Errorf("ошибка при слиянии PR: %w", err)
+			return pr, false, fmt.Errorf("ошибка при слиянии PR: %w", err)
 		}
-		dbStatus = "MERGED"
+		merged = true
 
 		err = tx.QueryRow(
 			ctx,
@@ -485,11 +883,11 @@ func (db *Database) MergePullRequest(ctx context.Context, prID string) (models.P
 			prID,
 		).Scan(&mergedAt)
 		if err != nil {
-			return pr, fmt.Errorf("ошибка при получении времени слияния: %w", err)
+			return pr, false, fmt.Errorf("ошибка при получении времени слияния: %w", err)
 		}
 	}
 
-	pr.Status = dbStatus
+	pr.Status = "MERGED"
 
 	if mergedAt != nil {
 		pr.MergedAt = mergedAt
@@ -506,43 +904,120 @@ func (db *Database) MergePullRequest(ctx context.Context, prID string) (models.P
 		prID,
 	).Scan(&reviewers)
 	if err != nil {
-		return pr, fmt.Errorf("ошибка при получении ревьюеров: %w", err)
+		return pr, false, fmt.Errorf("ошибка при получении ревьюеров: %w", err)
 	}
 	pr.AssignedReviewers = reviewers
 
-	return pr, nil
+	if merged {
+		err = enqueueWebhookEvent(ctx, tx, models.EventPullRequestMerged, models.PullRequestEvent{
+			PullRequestID:     pr.PullRequestID,
+			PullRequestName:   pr.PullRequestName,
+			AuthorID:          pr.AuthorID,
+			Status:            pr.Status,
+			AssignedReviewers: pr.AssignedReviewers,
+			MergedAt:          pr.MergedAt,
+		})
+		if err != nil {
+			return pr, false, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return pr, false, fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+
+	return pr, merged, nil
 }
 
-func (db *Database) ReassignPullRequest(ctx context.Context, prID, oldReviewerID, newReviewerID string) error {
+func (db *Database) ReassignPullRequest(ctx context.Context, prID, oldReviewerID string, policy models.ReviewerPolicy) (string, error) {
+	tx, err := db.conn(ctx).Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var status string
+	err = tx.QueryRow(
+		ctx,
+		"SELECT status FROM pull_requests WHERE id = $1 FOR UPDATE",
+		prID,
+	).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("ошибка при получении PR: %w", err)
+	}
+
+	switch status {
+	case "1":
+	case "3":
+		return "", ErrPRClosed
+	default:
+		return "", ErrPRMerged
+	}
+
 	var assignedID string
-	err := db.Pool.QueryRow(
+	err = tx.QueryRow(
 		ctx,
 		"SELECT id FROM pull_request_assigned_reviewers WHERE pull_request_id=$1 AND user_id=$2",
 		prID, oldReviewerID,
 	).Scan(&assignedID)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return pgx.ErrNoRows
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotAssigned
 		}
-		return fmt.Errorf("ошибка запроса: %w", err)
+		return "", fmt.Errorf("ошибка запроса: %w", err)
+	}
+
+	teamMates, err := availableTeamMatesForPR(ctx, tx, prID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(teamMates) == 0 {
+		return "", ErrNoCandidate
 	}
 
-	_, err = db.Pool.Exec(
+	picked, err := pickReviewers(ctx, tx, teamMates, 1, policy.Strategy)
+	if err != nil {
+		return "", err
+	}
+	newReviewerID := picked[0]
+
+	_, err = tx.Exec(
 		ctx,
 		"UPDATE pull_request_assigned_reviewers SET user_id=$1 WHERE id=$2",
 		newReviewerID, assignedID,
 	)
 	if err != nil {
-		return fmt.Errorf("ошибка обновления ревьюера: %w", err)
+		return "", fmt.Errorf("ошибка обновления ревьюера: %w", err)
 	}
 
-	return nil
+	err = enqueueWebhookEvent(ctx, tx, models.EventPullRequestReviewerReassigned, models.ReviewerReassignedEvent{
+		PullRequestID: prID,
+		OldReviewerID: oldReviewerID,
+		NewReviewerID: newReviewerID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err = enqueueReviewerSync(ctx, tx, prID, []string{oldReviewerID}); err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+
+	return newReviewerID, nil
 }
 
 func (db *Database) CheckStatusPR(ctx context.Context, prID string) (bool, error) {
 	var status string
 
-	err := db.Pool.QueryRow(ctx, "SELECT status FROM pull_requests WHERE id=$1", prID).Scan(&status)
+	err := db.conn(ctx).QueryRow(ctx, "SELECT status FROM pull_requests WHERE id=$1", prID).Scan(&status)
 	if err != nil {
 		return false, fmt.Errorf("ошибка запроса: %w", err)
 	}
@@ -554,7 +1029,7 @@ func (db *Database) PullRequestFullInformation(ctx context.Context, prID string)
 	var pr models.PullRequestResponse
 	pr.PullRequestID = prID
 
-	err := db.Pool.QueryRow(
+	err := db.conn(ctx).QueryRow(
 		ctx,
 		`
 		SELECT pr.name, pr.author_id, prs.name
@@ -567,13 +1042,13 @@ func (db *Database) PullRequestFullInformation(ctx context.Context, prID string)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return models.PullRequestResponse{}, fmt.Errorf("PR %s не найден: %w", prID, err)
+			return models.PullRequestResponse{}, fmt.Errorf("PR %s: %w", prID, ErrNotFound)
 		}
 		return models.PullRequestResponse{}, fmt.Errorf("ошибка при получении PR: %w", err)
 	}
 
 	var reviewers []string
-	err = db.Pool.QueryRow(
+	err = db.conn(ctx).QueryRow(
 		ctx,
 		`SELECT COALESCE(array_agg(user_id), '{}') 
 		 FROM pull_request_assigned_reviewers 
@@ -588,20 +1063,28 @@ func (db *Database) PullRequestFullInformation(ctx context.Context, prID string)
 	return pr, nil
 }
 
-func (db *Database) GetTeamMetrics(ctx context.Context) ([]models.TeamMetrics, int, int, error) {
+func (db *Database) GetTeamMetrics(ctx context.Context, scopeUserID string) ([]models.TeamMetrics, int, int, error) {
 	var totalPRs, totalTeams int
 
-	err := db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM pull_requests").Scan(&totalPRs)
+	err := db.conn(ctx).QueryRow(ctx, `
+        SELECT COUNT(*) FROM pull_requests pr
+        WHERE $1 = '' OR pr.author_id IN (
+            SELECT tm.user_id FROM team_members tm
+            WHERE tm.team_id IN (SELECT team_id FROM team_members WHERE user_id = $1)
+        )`, scopeUserID).Scan(&totalPRs)
 	if err != nil {
 		return nil, 0, 0, err
 	}
 
-	err = db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM teams").Scan(&totalTeams)
+	err = db.conn(ctx).QueryRow(ctx, `
+        SELECT COUNT(*) FROM teams t
+        WHERE $1 = '' OR t.id IN (SELECT team_id FROM team_members WHERE user_id = $1)`,
+		scopeUserID).Scan(&totalTeams)
 	if err != nil {
 		return nil, 0, 0, err
 	}
 
-	rows, err := db.Pool.Query(ctx, `
+	rows, err := db.conn(ctx).Query(ctx, `
         SELECT 
             t.id,
             t.name,
@@ -623,7 +1106,8 @@ func (db *Database) GetTeamMetrics(ctx context.Context) ([]models.TeamMetrics, i
             WHERE pr.id IS NOT NULL OR r.id IS NOT NULL
             GROUP BY tm.team_id
         ) participants ON t.id = participants.team_id
-    `)
+        WHERE $1 = '' OR t.id IN (SELECT team_id FROM team_members WHERE user_id = $1)
+    `, scopeUserID)
 	if err != nil {
 		return nil, 0, 0, err
 	}
@@ -640,3 +1124,102 @@ func (db *Database) GetTeamMetrics(ctx context.Context) ([]models.TeamMetrics, i
 
 	return metrics, totalPRs, totalTeams, nil
 }
+
+const idempotencyReserveAttempts = 3
+
+func (db *Database) ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	_, err := db.Pool.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at < NOW()")
+	if err != nil {
+		return models.IdempotencyRecord{}, false, fmt.Errorf("ошибка очистки ключей идемпотентности: %w", err)
+	}
+
+	// ключ могут освободить между INSERT и SELECT — тогда пробуем занять его заново
+	for range idempotencyReserveAttempts {
+		tag, err := db.Pool.Exec(
+			ctx,
+			`INSERT INTO idempotency_keys (key, scope, fingerprint, expires_at)
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (key, scope) DO NOTHING`,
+			rec.Key, rec.Scope, rec.Fingerprint, rec.ExpiresAt,
+		)
+		if err != nil {
+			return models.IdempotencyRecord{}, false, fmt.Errorf("ошибка сохранения ключа идемпотентности: %w", err)
+		}
+
+		if tag.RowsAffected() == 1 {
+			return rec, true, nil
+		}
+
+		var existing models.IdempotencyRecord
+		err = db.Pool.QueryRow(
+			ctx,
+			`SELECT key, scope, fingerprint, status_code, content_type, COALESCE(response_body, ''::BYTEA), expires_at
+			 FROM idempotency_keys
+			 WHERE key = $1 AND scope = $2`,
+			rec.Key, rec.Scope,
+		).Scan(&existing.Key, &existing.Scope, &existing.Fingerprint, &existing.StatusCode,
+			&existing.ContentType, &existing.ResponseBody, &existing.ExpiresAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return models.IdempotencyRecord{}, false, fmt.Errorf("ошибка чтения ключа идемпотентности: %w", err)
+		}
+
+		return existing, false, nil
+	}
+
+	return models.IdempotencyRecord{}, false, ErrIdempotencyInProgress
+}
+
+func (db *Database) CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error {
+	_, err := db.Pool.Exec(
+		ctx,
+		`UPDATE idempotency_keys
+		 SET status_code = $3, content_type = $4, response_body = $5
+		 WHERE key = $1 AND scope = $2`,
+		rec.Key, rec.Scope, rec.StatusCode, rec.ContentType, rec.ResponseBody,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения ответа: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) ReleaseIdempotencyKey(ctx context.Context, key, scope string) error {
+	_, err := db.Pool.Exec(ctx, "DELETE FROM idempotency_keys WHERE key = $1 AND scope = $2", key, scope)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления ключа идемпотентности: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) GetOpenReviewLoad(ctx context.Context) (map[string]int, error) {
+	load := make(map[string]int)
+
+	err := db.ExecuteQuery(
+		ctx,
+		`SELECT prar.user_id, COUNT(*)
+		 FROM pull_request_assigned_reviewers prar
+		 INNER JOIN pull_requests pr ON pr.id = prar.pull_request_id
+		 WHERE pr.status = '1'
+		 GROUP BY prar.user_id`,
+		nil,
+		func(rows pgx.Rows) error {
+			var userID string
+			var count int
+			if err := rows.Scan(&userID, &count); err != nil {
+				return err
+			}
+			load[userID] = count
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return load, nil
+}
@@ -1,9 +1,24 @@
 package database
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestNewDatabase(t *testing.T) {
@@ -16,9 +31,764 @@ func TestNewDatabase(t *testing.T) {
 		SSLMode:  "disable",
 	}
 
-	db := New(cfg)
+	db := New(cfg, nil)
 	assert.NotNil(t, db)
 	assert.Equal(t, "postgres", db.User)
 	assert.Equal(t, 5432, db.Port)
 	assert.Nil(t, db.Pool)
 }
+
+func TestDatabase_NotConnected(t *testing.T) {
+	db := New(config.PostreSQLConfig{}, nil)
+
+	select {
+	case <-db.Ready():
+		t.Fatal("database must not be ready before connecting")
+	default:
+	}
+
+	assert.ErrorIs(t, db.Ping(context.Background()), ErrNotConnected)
+	assert.ErrorIs(t, db.CheckMigrations(context.Background()), ErrNotConnected)
+}
+
+func TestDatabase_CheckMigrations(t *testing.T) {
+	db := newTestDatabase(t)
+
+	require.NoError(t, db.Ping(context.Background()))
+	require.NoError(t, db.CheckMigrations(context.Background()))
+}
+
+var testReviewerPolicy = models.ReviewerPolicy{Count: 2, Strategy: models.ReviewerStrategyRandom}
+
+func newTestDatabase(t *testing.T) *Database {
+	t.Helper()
+
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		t.Skip("DB_DSN is not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	require.NoError(t, pool.Ping(context.Background()))
+
+	return &Database{Pool: pool, log: slog.Default()}
+}
+
+func createTestTeam(t *testing.T, db *Database, size int) (teamName string, userIDs []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	teamName = "team-" + suffix
+
+	members := make([]models.RequestMembers, 0, size)
+	for i := 0; i < size; i++ {
+		id := fmt.Sprintf("u-%s-%d", suffix, i)
+		_, err := db.Pool.Exec(ctx, "INSERT INTO users (id, name) VALUES ($1, $2)", id, "user "+id)
+		require.NoError(t, err)
+		userIDs = append(userIDs, id)
+		members = append(members, models.RequestMembers{UserID: id, Username: "user " + id, IsActive: true})
+	}
+
+	_, err := db.CreateTeam(ctx, models.RequestTeamAddResponse{TeamName: teamName, Members: members})
+	require.NoError(t, err)
+
+	return teamName, userIDs
+}
+
+func TestCreatePullRequestWithReviewers_Concurrent(t *testing.T) {
+	db := newTestDatabase(t)
+	_, userIDs := createTestTeam(t, db, 5)
+	ctx := context.Background()
+
+	prID := "pr-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	const workers = 20
+	var (
+		wg        sync.WaitGroup
+		created   atomic.Int32
+		conflicts atomic.Int32
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := db.CreatePullRequestWithReviewers(ctx, models.PullRequestCreateRequest{
+				PullRequestId:   prID,
+				PullRequestName: "concurrent",
+				AuthorID:        userIDs[0],
+			}, testReviewerPolicy)
+
+			switch {
+			case err == nil:
+				created.Add(1)
+			case errors.Is(err, ErrPRExists):
+				conflicts.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), created.Load())
+	assert.Equal(t, int32(workers-1), conflicts.Load())
+
+	var prCount, reviewerCount int
+	require.NoError(t, db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM pull_requests WHERE id = $1", prID).Scan(&prCount))
+	require.NoError(t, db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM pull_request_assigned_reviewers WHERE pull_request_id = $1", prID).Scan(&reviewerCount))
+	assert.Equal(t, 1, prCount)
+	assert.Equal(t, 2, reviewerCount)
+}
+
+func TestCreatePullRequestWithReviewers_NoReviewers(t *testing.T) {
+	db := newTestDatabase(t)
+	_, userIDs := createTestTeam(t, db, 1)
+
+	prID := "pr-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	pr, err := db.CreatePullRequestWithReviewers(context.Background(), models.PullRequestCreateRequest{
+		PullRequestId:   prID,
+		PullRequestName: "lonely",
+		AuthorID:        userIDs[0],
+	}, testReviewerPolicy)
+
+	require.NoError(t, err)
+	assert.Equal(t, "OPEN", pr.Status)
+	assert.Empty(t, pr.AssignedReviewers)
+	assert.NotNil(t, pr.AssignedReviewers)
+}
+
+func TestCreatePullRequestWithReviewers_UnknownAuthorLeavesNoOrphan(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	prID := "pr-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	_, err := db.CreatePullRequestWithReviewers(ctx, models.PullRequestCreateRequest{
+		PullRequestId:   prID,
+		PullRequestName: "orphan",
+		AuthorID:        "missing-" + prID,
+	}, testReviewerPolicy)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	exists, err := db.CheckPR(ctx, prID)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestReassignPullRequest_ConcurrentWithMerge(t *testing.T) {
+	db := newTestDatabase(t)
+	_, userIDs := createTestTeam(t, db, 8)
+	ctx := context.Background()
+
+	prID := "pr-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	_, err := db.CreatePullRequestWithReviewers(ctx, models.PullRequestCreateRequest{
+		PullRequestId:   prID,
+		PullRequestName: "stress",
+		AuthorID:        userIDs[0],
+	}, testReviewerPolicy)
+	require.NoError(t, err)
+
+	const workers = 16
+	var (
+		wg     sync.WaitGroup
+		merged models.PullRequest
+		start  = make(chan struct{})
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 10; j++ {
+				info, err := db.PullRequestFullInformation(ctx, prID)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if len(info.AssignedReviewers) == 0 {
+					continue
+				}
+
+				_, err = db.ReassignPullRequest(ctx, prID, info.AssignedReviewers[j%len(info.AssignedReviewers)], testReviewerPolicy)
+				switch {
+				case err == nil, errors.Is(err, ErrNotAssigned), errors.Is(err, ErrNoCandidate):
+				case errors.Is(err, ErrPRMerged):
+					return
+				default:
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-start
+		time.Sleep(10 * time.Millisecond)
+		var err error
+		merged, _, err = db.MergePullRequest(ctx, prID)
+		if err != nil {
+			t.Errorf("merge failed: %v", err)
+		}
+	}()
+
+	close(start)
+	wg.Wait()
+
+	final, err := db.PullRequestFullInformation(ctx, prID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "MERGED", final.Status)
+	assert.ElementsMatch(t, merged.AssignedReviewers, final.AssignedReviewers)
+	assert.Len(t, final.AssignedReviewers, 2)
+	assert.NotContains(t, final.AssignedReviewers, userIDs[0])
+
+	_, err = db.ReassignPullRequest(ctx, prID, final.AssignedReviewers[0], testReviewerPolicy)
+	assert.ErrorIs(t, err, ErrPRMerged)
+}
+
+func TestRunDatabase_GivesUpAfterAttempts(t *testing.T) {
+	db := New(config.PostreSQLConfig{
+		User:              "user",
+		Password:          "password",
+		Host:              "127.0.0.1",
+		Port:              1,
+		DB:                "avito",
+		SSLMode:           "disable",
+		ConnectAttempts:   3,
+		ConnectBackoff:    time.Millisecond,
+		ConnectMaxBackoff: 2 * time.Millisecond,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := db.RunDatabase(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "3")
+
+	select {
+	case <-db.Ready():
+		t.Fatal("database must not be ready after failed connect")
+	default:
+	}
+}
+
+func TestRunDatabase_StopsRetryingOnCancel(t *testing.T) {
+	db := New(config.PostreSQLConfig{
+		User:           "user",
+		Password:       "password",
+		Host:           "127.0.0.1",
+		Port:           1,
+		DB:             "avito",
+		SSLMode:        "disable",
+		ConnectBackoff: time.Hour,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := db.RunDatabase(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestInTx_RollsBackOnError(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	teamName, userIDs := createTestTeam(t, db, 1)
+	boom := errors.New("audit failed")
+
+	err := db.InTx(ctx, func(ctx context.Context) error {
+		if _, err := db.UpdateActive(ctx, userIDs[0], false); err != nil {
+			return err
+		}
+		return boom
+	})
+	require.ErrorIs(t, err, boom)
+
+	user, err := db.GetUser(ctx, userIDs[0])
+	require.NoError(t, err)
+	assert.True(t, user.IsActive)
+	assert.Equal(t, teamName, user.TeamName)
+}
+
+func TestAPIKeys_AuthenticateAndRevoke(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	admin := true
+	created, err := db.CreateAPIKey(ctx, models.APIKey{
+		Name:           "ci",
+		ServiceAccount: "bot-" + suffix,
+		Prefix:         "avk_" + suffix,
+		Hash:           "hash-" + suffix,
+		Scopes:         []string{models.ScopePullRequestMerge},
+		CreatedBy:      "admin",
+
+		ServiceAccountAdmin: &admin,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, created.LastUsedAt)
+
+	key, err := db.AuthenticateAPIKey(ctx, "hash-"+suffix)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, key.ID)
+	require.NotNil(t, key.ServiceAccountAdmin)
+	assert.True(t, *key.ServiceAccountAdmin)
+	assert.NotNil(t, key.LastUsedAt)
+	assert.True(t, key.HasScope(models.ScopePullRequestMerge))
+
+	_, err = db.RevokeAPIKey(ctx, created.ID)
+	require.NoError(t, err)
+
+	_, err = db.AuthenticateAPIKey(ctx, "hash-"+suffix)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = db.RevokeAPIKey(ctx, "missing-"+suffix)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, userIDs := createTestTeam(t, db, 1)
+	_, err = db.CreateAPIKey(ctx, models.APIKey{
+		Name:           "ci",
+		ServiceAccount: userIDs[0],
+		Prefix:         "avk_u" + suffix,
+		Hash:           "hash-u" + suffix,
+		Scopes:         []string{models.ScopePullRequestMerge},
+		CreatedBy:      "admin",
+	})
+	assert.ErrorIs(t, err, ErrNotServiceAccount)
+}
+
+func TestResolveOIDCUser(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	identity := models.OIDCIdentity{
+		Subject: "sub-" + suffix,
+		Email:   suffix + "@example.com",
+		Name:    "SSO " + suffix,
+		Teams:   []string{"sso-team-" + suffix},
+	}
+
+	_, err := db.ResolveOIDCUser(ctx, identity, "sub", false)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	created, err := db.ResolveOIDCUser(ctx, identity, "sub", true)
+	require.NoError(t, err)
+	assert.Equal(t, identity.Name, created.Username)
+
+	again, err := db.ResolveOIDCUser(ctx, identity, "sub", true)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, again.ID)
+
+	identity.Email = strings.ToUpper(identity.Email)
+	byEmail, err := db.ResolveOIDCUser(ctx, identity, "email", false)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, byEmail.ID)
+
+	var memberships int
+	require.NoError(t, db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM team_members WHERE user_id = $1", created.ID).Scan(&memberships))
+	assert.Equal(t, 1, memberships)
+
+	_, err = db.UpdateActive(ctx, created.ID, false)
+	require.NoError(t, err)
+	_, err = db.ResolveOIDCUser(ctx, identity, "sub", true)
+	assert.ErrorIs(t, err, ErrUserInactive)
+}
+
+func TestSetUserIdentity(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+	_, userIDs := createTestTeam(t, db, 2)
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	identity := models.OIDCIdentity{Subject: "sub-" + suffix, Email: suffix + "@example.com"}
+
+	_, err := db.ResolveOIDCUser(ctx, identity, "sub", false)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	subject, email := identity.Subject, strings.ToUpper(identity.Email)
+	saved, err := db.SetUserIdentity(ctx, models.UserIdentityRequest{UserID: userIDs[0], Email: &email, OIDCSubject: &subject})
+	require.NoError(t, err)
+	assert.Equal(t, models.UserIdentity{UserID: userIDs[0], Email: email, OIDCSubject: subject}, saved)
+
+	bySubject, err := db.ResolveOIDCUser(ctx, identity, "sub", false)
+	require.NoError(t, err)
+	assert.Equal(t, userIDs[0], bySubject.ID)
+
+	byEmail, err := db.ResolveOIDCUser(ctx, identity, "email", false)
+	require.NoError(t, err)
+	assert.Equal(t, userIDs[0], byEmail.ID)
+
+	_, err = db.SetUserIdentity(ctx, models.UserIdentityRequest{UserID: userIDs[1], OIDCSubject: &subject})
+	assert.ErrorIs(t, err, ErrOIDCSubjectTaken)
+
+	empty := ""
+	cleared, err := db.SetUserIdentity(ctx, models.UserIdentityRequest{UserID: userIDs[0], OIDCSubject: &empty})
+	require.NoError(t, err)
+	assert.Equal(t, email, cleared.Email)
+	assert.Empty(t, cleared.OIDCSubject)
+
+	_, err = db.GetUserIdentity(ctx, "missing-"+suffix)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReserveIdempotencyKey_ExpiresInAnyTimeZone(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	rec := models.IdempotencyRecord{
+		Key:         "key-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		Scope:       "u1 POST /pullRequest/create",
+		Fingerprint: "a",
+		ExpiresAt:   time.Now().Add(-time.Minute).In(time.FixedZone("UTC+10", 10*60*60)),
+	}
+	_, reserved, err := db.ReserveIdempotencyKey(ctx, rec)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	rec.Fingerprint = "b"
+	rec.ExpiresAt = time.Now().Add(time.Hour)
+	_, reserved, err = db.ReserveIdempotencyKey(ctx, rec)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
+
+func TestAuditLog_WriteListPurge(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	actor := "admin-" + suffix
+	for _, target := range []string{"pr-1", "pr-2", "pr-3"} {
+		require.NoError(t, db.WriteAuditEntry(ctx, models.AuditEntry{
+			Actor:     actor,
+			Action:    "pullRequest:merge",
+			Target:    target,
+			RequestID: "req-" + suffix,
+			After:     []byte(`{"status":"MERGED"}`),
+		}))
+	}
+
+	page, err := db.ListAuditEntries(ctx, models.AuditFilter{Actor: actor, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "pr-3", page[0].Target)
+	assert.Nil(t, page[0].Before)
+	assert.JSONEq(t, `{"status":"MERGED"}`, string(page[0].After))
+
+	rest, err := db.ListAuditEntries(ctx, models.AuditFilter{Actor: actor, Cursor: page[1].ID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, "pr-1", rest[0].Target)
+
+	_, err = db.Pool.Exec(ctx, "UPDATE audit_log SET target = 'x' WHERE id = $1", rest[0].ID)
+	assert.Error(t, err)
+
+	_, err = db.PurgeAuditLog(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	left, err := db.ListAuditEntries(ctx, models.AuditFilter{Actor: actor, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, left)
+}
+
+func TestWebhooks_OutboxDelivery(t *testing.T) {
+	db := newTestDatabase(t)
+	_, userIDs := createTestTeam(t, db, 3)
+	ctx := context.Background()
+
+	sub, err := db.CreateWebhookSubscription(ctx, models.WebhookSubscription{
+		URL:        "https://example.com/hook",
+		Secret:     "secret",
+		EventTypes: []string{models.EventPullRequestCreated, models.EventPullRequestMerged},
+		CreatedBy:  "admin",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = db.DeleteWebhookSubscription(context.Background(), sub.ID) })
+
+	prID := "pr-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	_, err = db.CreatePullRequestWithReviewers(ctx, models.PullRequestCreateRequest{
+		PullRequestId:   prID,
+		PullRequestName: "hooks",
+		AuthorID:        userIDs[0],
+	}, testReviewerPolicy)
+	require.NoError(t, err)
+
+	_, _, err = db.MergePullRequest(ctx, prID)
+	require.NoError(t, err)
+	_, _, err = db.MergePullRequest(ctx, prID)
+	require.NoError(t, err)
+
+	deliveries, err := db.ListWebhookDeliveries(ctx, models.WebhookDeliveryFilter{SubscriptionID: sub.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, models.EventPullRequestMerged, deliveries[0].EventType)
+	assert.Equal(t, models.EventPullRequestCreated, deliveries[1].EventType)
+
+	claimed, err := db.ClaimWebhookDeliveries(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+	var ours []models.PendingWebhook
+	for _, p := range claimed {
+		if p.URL == sub.URL && p.Secret == sub.Secret {
+			ours = append(ours, p)
+		}
+	}
+	require.Len(t, ours, 2)
+	assert.JSONEq(t, `"`+prID+`"`, string(mustField(t, ours[0].Event.Data, "pull_request_id")))
+
+	again, err := db.ClaimWebhookDeliveries(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+	for _, p := range again {
+		assert.NotEqual(t, ours[0].DeliveryID, p.DeliveryID)
+	}
+
+	require.NoError(t, db.RecordWebhookAttempt(ctx, ours[0].DeliveryID, models.WebhookAttempt{Delivered: true, StatusCode: 200}))
+	require.NoError(t, db.RecordWebhookAttempt(ctx, ours[1].DeliveryID, models.WebhookAttempt{StatusCode: 500, Error: "boom"}))
+
+	failed, err := db.ListWebhookDeliveries(ctx, models.WebhookDeliveryFilter{SubscriptionID: sub.ID, Status: models.DeliveryFailed, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, 1, failed[0].Attempts)
+	require.NotNil(t, failed[0].LastError)
+	assert.Equal(t, "boom", *failed[0].LastError)
+
+	redelivered, err := db.RedeliverWebhook(ctx, failed[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryPending, redelivered.Status)
+	assert.Zero(t, redelivered.Attempts)
+}
+
+func mustField(t *testing.T, data []byte, field string) json.RawMessage {
+	t.Helper()
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields[field]
+}
+
+func TestIntegrations_IdentityAndLifecycle(t *testing.T) {
+	db := newTestDatabase(t)
+	_, userIDs := createTestTeam(t, db, 3)
+	ctx := context.Background()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	login := "Octo-" + suffix
+	m, err := db.SetIdentityMapping(ctx, models.IdentityMapping{Provider: models.ProviderGitHub, Login: login, UserID: userIDs[0]})
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = db.DeleteIdentityMapping(context.Background(), m.Provider, m.Login) })
+
+	userID, err := db.ResolveIdentity(ctx, models.ProviderGitHub, login, "")
+	require.NoError(t, err)
+	assert.Equal(t, userIDs[0], userID)
+
+	// событие с логином и id запоминает id, дальше автор находится без логина
+	externalID := "9" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	_, err = db.ResolveIdentity(ctx, models.ProviderGitHub, "", externalID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = db.ResolveIdentity(ctx, models.ProviderGitHub, login, externalID)
+	require.NoError(t, err)
+	userID, err = db.ResolveIdentity(ctx, models.ProviderGitHub, "", externalID)
+	require.NoError(t, err)
+	assert.Equal(t, userIDs[0], userID)
+
+	_, err = db.SetIdentityMapping(ctx, models.IdentityMapping{Provider: models.ProviderGitHub, Login: "other-" + login, ExternalID: externalID, UserID: userIDs[1]})
+	assert.ErrorIs(t, err, ErrExternalIDTaken)
+
+	_, err = db.SetIdentityMapping(ctx, models.IdentityMapping{Provider: models.ProviderGitHub, Login: login, UserID: "missing-" + suffix})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	fresh, err := db.ReserveIntegrationDelivery(ctx, models.ProviderGitHub, "d-"+suffix)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+	fresh, err = db.ReserveIntegrationDelivery(ctx, models.ProviderGitHub, "d-"+suffix)
+	require.NoError(t, err)
+	assert.False(t, fresh)
+	require.NoError(t, db.ReleaseIntegrationDelivery(ctx, models.ProviderGitHub, "d-"+suffix))
+	fresh, err = db.ReserveIntegrationDelivery(ctx, models.ProviderGitHub, "d-"+suffix)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	deleted, err := db.PurgeIntegrationDeliveries(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Positive(t, deleted)
+	fresh, err = db.ReserveIntegrationDelivery(ctx, models.ProviderGitHub, "d-"+suffix)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	prID := "github:acme/api#" + suffix
+	_, err = db.CreatePullRequestWithReviewers(ctx, models.PullRequestCreateRequest{
+		PullRequestId:   prID,
+		PullRequestName: "integration",
+		AuthorID:        userIDs[0],
+	}, testReviewerPolicy)
+	require.NoError(t, err)
+
+	closed, err := db.ClosePullRequest(ctx, prID)
+	require.NoError(t, err)
+	assert.True(t, closed)
+
+	_, _, err = db.MergePullRequest(ctx, prID)
+	assert.ErrorIs(t, err, ErrPRClosed)
+
+	reopened, err := db.ReopenPullRequest(ctx, prID)
+	require.NoError(t, err)
+	assert.True(t, reopened)
+
+	_, _, err = db.MergePullRequest(ctx, prID)
+	require.NoError(t, err)
+
+	_, err = db.ClosePullRequest(ctx, prID)
+	assert.ErrorIs(t, err, ErrPRMerged)
+}
+
+func TestReviewerSync_EnqueueClaimRecord(t *testing.T) {
+	db := newTestDatabase(t)
+	teamName, userIDs := createTestTeam(t, db, 4)
+	ctx := context.Background()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	create := func(prID string) models.PullRequestResponse {
+		pr, err := db.CreatePullRequestWithReviewers(ctx, models.PullRequestCreateRequest{
+			PullRequestId:   prID,
+			PullRequestName: "sync",
+			AuthorID:        userIDs[0],
+		}, testReviewerPolicy)
+		require.NoError(t, err)
+		return pr
+	}
+
+	// синхронизация выключена — в очередь не попадает
+	create("github:acme/api#off-" + suffix)
+	_, err := db.GetReviewerSync(ctx, "github:acme/api#off-"+suffix)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = db.SetTeamReviewerSync(ctx, teamName, true)
+	require.NoError(t, err)
+	_, err = db.SetTeamReviewerSync(ctx, "missing-"+suffix, true)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// PR не из интеграции не синхронизируется даже при включённой настройке
+	create("pr-" + suffix)
+	_, err = db.GetReviewerSync(ctx, "pr-"+suffix)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	prID := "github:acme/api#" + suffix
+	pr := create(prID)
+	status, err := db.GetReviewerSync(ctx, prID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReviewerSyncPending, status.Status)
+
+	var claimed models.PendingReviewerSync
+	for _, p := range claimAll(t, db) {
+		if p.PullRequestID == prID {
+			claimed = p
+		}
+	}
+	require.Equal(t, prID, claimed.PullRequestID)
+	assert.ElementsMatch(t, pr.AssignedReviewers, claimed.Reviewers)
+
+	newReviewer, err := db.ReassignPullRequest(ctx, prID, pr.AssignedReviewers[0], testReviewerPolicy)
+	require.NoError(t, err)
+
+	// результат попытки по устаревшей ревизии не применяется
+	require.NoError(t, db.RecordReviewerSync(ctx, prID, claimed.Revision, models.ReviewerSyncAttempt{Synced: true}))
+	status, err = db.GetReviewerSync(ctx, prID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReviewerSyncPending, status.Status)
+	assert.Equal(t, []string{pr.AssignedReviewers[0]}, status.RemovedReviewers)
+
+	for _, p := range claimAll(t, db) {
+		if p.PullRequestID == prID {
+			claimed = p
+		}
+	}
+	assert.Contains(t, claimed.Reviewers, newReviewer)
+	require.NoError(t, db.RecordReviewerSync(ctx, prID, claimed.Revision, models.ReviewerSyncAttempt{Synced: true}))
+
+	status, err = db.GetReviewerSync(ctx, prID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReviewerSyncSynced, status.Status)
+	assert.Empty(t, status.RemovedReviewers)
+	assert.NotNil(t, status.SyncedAt)
+}
+
+func claimAll(t *testing.T, db *Database) []models.PendingReviewerSync {
+	t.Helper()
+
+	claimed, err := db.ClaimReviewerSyncs(context.Background(), 1000, time.Minute)
+	require.NoError(t, err)
+	return claimed
+}
+
+func TestCodeowners_PreferOwnersOfChangedFiles(t *testing.T) {
+	db := newTestDatabase(t)
+	teamName, userIDs := createTestTeam(t, db, 6)
+	ctx := context.Background()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	repository := "billing-" + suffix
+	_, err := db.SetCodeowners(ctx, models.Codeowners{
+		TeamName:   teamName,
+		Repository: repository,
+		Content:    fmt.Sprintf("* @%s\n/internal/db/ @%s @%s @%s\n", userIDs[5], userIDs[0], userIDs[3], userIDs[4]),
+	})
+	require.NoError(t, err)
+	_, err = db.SetCodeowners(ctx, models.Codeowners{TeamName: "missing-" + suffix, Repository: repository, Content: "* @x"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	create := func(prID, repository string, files ...string) []string {
+		pr, err := db.CreatePullRequestWithReviewers(ctx, models.PullRequestCreateRequest{
+			PullRequestId:   prID,
+			PullRequestName: "codeowners",
+			AuthorID:        userIDs[0],
+			Repository:      repository,
+			ChangedFiles:    files,
+		}, testReviewerPolicy)
+		require.NoError(t, err)
+		return pr.AssignedReviewers
+	}
+
+	// автор среди владельцев, но себе не назначается
+	reviewers := create("pr-a-"+suffix, repository, "internal/db/pool.go", "internal/db/tx.go")
+	assert.ElementsMatch(t, []string{userIDs[3], userIDs[4]}, reviewers)
+
+	// неактивный владелец пропускается, недостающий ревьюер добирается из команды
+	_, err = db.UpdateActive(ctx, userIDs[4], false)
+	require.NoError(t, err)
+	reviewers = create("pr-b-"+suffix, repository, "internal/db/pool.go")
+	require.Len(t, reviewers, 2)
+	assert.Contains(t, reviewers, userIDs[3])
+	assert.NotContains(t, reviewers, userIDs[4])
+
+	// владельцы разных путей объединяются
+	reviewers = create("pr-c-"+suffix, repository, "README.md", "internal/db/pool.go")
+	assert.ElementsMatch(t, []string{userIDs[3], userIDs[5]}, reviewers)
+
+	// для PR из интеграции репозиторий берётся из id, логины — через identity_mappings
+	_, err = db.SetIdentityMapping(ctx, models.IdentityMapping{Provider: models.ProviderGitHub, Login: "Owner-" + suffix, UserID: userIDs[2]})
+	require.NoError(t, err)
+	_, err = db.SetCodeowners(ctx, models.Codeowners{
+		TeamName:   teamName,
+		Repository: "github:acme/" + suffix,
+		Content:    "*.go @owner-" + suffix + " @acme/backend\n",
+	})
+	require.NoError(t, err)
+
+	reviewers = create("github:acme/"+suffix+"#1", "", "cmd/app/main.go")
+	require.Len(t, reviewers, 2)
+	assert.Contains(t, reviewers, userIDs[2])
+
+	c, err := db.DeleteCodeowners(ctx, teamName, repository)
+	require.NoError(t, err)
+	assert.Equal(t, repository, c.Repository)
+	_, err = db.GetCodeowners(ctx, teamName, repository)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
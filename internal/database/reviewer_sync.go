@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const reviewerSyncColumns = `pull_request_id, provider, status, attempts, removed_reviewers,
+	next_attempt_at, last_attempt_at, last_error, synced_at, updated_at`
+
+func scanReviewerSync(row pgx.Row) (models.ReviewerSync, error) {
+	var s models.ReviewerSync
+	err := row.Scan(&s.PullRequestID, &s.Provider, &s.Status, &s.Attempts, &s.RemovedReviewers,
+		&s.NextAttemptAt, &s.LastAttemptAt, &s.LastError, &s.SyncedAt, &s.UpdatedAt)
+	return s, err
+}
+
+// enqueueReviewerSync ставит PR в очередь на отправку ревьюеров в code host в той же транзакции,
+// что и назначение. PR не из интеграции и команды без включённой синхронизации пропускаются.
+func enqueueReviewerSync(ctx context.Context, q querier, prID string, removed []string) error {
+	provider := models.PullRequestProvider(prID)
+	if provider == "" {
+		return nil
+	}
+	if removed == nil {
+		removed = []string{}
+	}
+
+	_, err := q.Exec(
+		ctx,
+		`INSERT INTO reviewer_sync (pull_request_id, provider, removed_reviewers)
+		 SELECT pr.id, $2::varchar, $3::varchar[]
+		 FROM pull_requests pr
+		 WHERE pr.id = $1 AND EXISTS (
+			SELECT 1 FROM team_members tm
+			INNER JOIN team_reviewer_sync trs ON trs.team_id = tm.team_id
+			WHERE tm.user_id = pr.author_id AND trs.enabled
+		 )
+		 ON CONFLICT (pull_request_id) DO UPDATE
+		 SET status = 'pending',
+		     revision = reviewer_sync.revision + 1,
+		     attempts = 0,
+		     next_attempt_at = NOW(),
+		     removed_reviewers = reviewer_sync.removed_reviewers || EXCLUDED.removed_reviewers,
+		     updated_at = NOW()`,
+		prID, provider, removed,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка постановки синхронизации ревьюеров: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) SetTeamReviewerSync(ctx context.Context, teamName string, enabled bool) (models.TeamReviewerSync, error) {
+	teamID, exists, err := db.ReturnTeamID(ctx, teamName)
+	if err != nil {
+		return models.TeamReviewerSync{}, err
+	}
+	if !exists {
+		return models.TeamReviewerSync{}, ErrNotFound
+	}
+
+	_, err = db.conn(ctx).Exec(
+		ctx,
+		`INSERT INTO team_reviewer_sync (team_id, enabled) VALUES ($1, $2)
+		 ON CONFLICT (team_id) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()`,
+		teamID, enabled,
+	)
+	if err != nil {
+		return models.TeamReviewerSync{}, fmt.Errorf("ошибка сохранения настройки синхронизации: %w", err)
+	}
+
+	return models.TeamReviewerSync{TeamName: teamName, Enabled: enabled}, nil
+}
+
+func (db *Database) GetReviewerSync(ctx context.Context, prID string) (models.ReviewerSync, error) {
+	s, err := scanReviewerSync(db.conn(ctx).QueryRow(
+		ctx,
+		`SELECT `+reviewerSyncColumns+` FROM reviewer_sync WHERE pull_request_id = $1`,
+		prID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ReviewerSync{}, ErrNotFound
+		}
+		return models.ReviewerSync{}, fmt.Errorf("ошибка получения статуса синхронизации: %w", err)
+	}
+
+	return s, nil
+}
+
+// ClaimReviewerSyncs забирает PR, ожидающие синхронизации, и сдвигает их next_attempt_at на lease.
+func (db *Database) ClaimReviewerSyncs(ctx context.Context, limit int, lease time.Duration) ([]models.PendingReviewerSync, error) {
+	pending := make([]models.PendingReviewerSync, 0, limit)
+
+	err := db.ExecuteQuery(
+		ctx,
+		`WITH claimed AS (
+			UPDATE reviewer_sync
+			SET next_attempt_at = NOW() + $2::float8 * INTERVAL '1 millisecond'
+			WHERE pull_request_id IN (
+				SELECT pull_request_id FROM reviewer_sync
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING pull_request_id, provider, revision, attempts, removed_reviewers
+		 )
+		 SELECT c.pull_request_id, c.provider, c.revision, c.attempts, c.removed_reviewers,
+		        COALESCE(array_agg(prar.user_id ORDER BY prar.user_id) FILTER (WHERE prar.user_id IS NOT NULL), '{}')
+		 FROM claimed c
+		 LEFT JOIN pull_request_assigned_reviewers prar ON prar.pull_request_id = c.pull_request_id
+		 GROUP BY c.pull_request_id, c.provider, c.revision, c.attempts, c.removed_reviewers
+		 ORDER BY c.pull_request_id`,
+		[]any{limit, lease.Milliseconds()},
+		func(rows pgx.Rows) error {
+			var p models.PendingReviewerSync
+			if err := rows.Scan(&p.PullRequestID, &p.Provider, &p.Revision, &p.Attempts, &p.RemovedReviewers, &p.Reviewers); err != nil {
+				return err
+			}
+			pending = append(pending, p)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// RecordReviewerSync сохраняет результат попытки, только если с момента claim не было нового
+// назначения: иначе PR уже снова в очереди с актуальной ревизией.
+func (db *Database) RecordReviewerSync(ctx context.Context, prID string, revision int, attempt models.ReviewerSyncAttempt) error {
+	status := models.ReviewerSyncPending
+	switch {
+	case attempt.Synced:
+		status = models.ReviewerSyncSynced
+	case attempt.RetryAfter <= 0:
+		status = models.ReviewerSyncFailed
+	}
+
+	var lastError *string
+	if attempt.Error != "" {
+		lastError = &attempt.Error
+	}
+
+	_, err := db.conn(ctx).Exec(
+		ctx,
+		`UPDATE reviewer_sync
+		 SET status = $3,
+		     attempts = attempts + 1,
+		     last_attempt_at = NOW(),
+		     last_error = $4,
+		     next_attempt_at = NOW() + $5::float8 * INTERVAL '1 millisecond',
+		     synced_at = CASE WHEN $3 = 'synced' THEN NOW() ELSE synced_at END,
+		     removed_reviewers = CASE WHEN $3 = 'synced' THEN '{}' ELSE removed_reviewers END,
+		     updated_at = NOW()
+		 WHERE pull_request_id = $1 AND revision = $2`,
+		prID, revision, status, lastError, attempt.RetryAfter.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения результата синхронизации: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const webhookSubscriptionColumns = `id, url, event_types, created_by, created_at`
+
+const webhookDeliveryColumns = `d.id, d.event_id, e.type, d.subscription_id, d.status, d.attempts, d.next_attempt_at,
+	d.last_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at`
+
+func scanWebhookSubscription(row pgx.Row) (models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := row.Scan(&sub.ID, &sub.URL, &sub.EventTypes, &sub.CreatedBy, &sub.CreatedAt)
+	return sub, err
+}
+
+func scanWebhookDelivery(row pgx.Row) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := row.Scan(&d.ID, &d.EventID, &d.EventType, &d.SubscriptionID, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+	return d, err
+}
+
+// enqueueWebhookEvent пишет событие в outbox в рамках транзакции вызывающего,
+// по одной доставке на каждую подписку. Без подписчиков событие не сохраняется.
+func enqueueWebhookEvent(ctx context.Context, q querier, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования события %s: %w", eventType, err)
+	}
+
+	_, err = q.Exec(
+		ctx,
+		`WITH subs AS (
+			SELECT id FROM webhook_subscriptions WHERE $1 = ANY(event_types)
+		 ), ev AS (
+			INSERT INTO webhook_events (type, payload)
+			SELECT $1, $2::jsonb WHERE EXISTS (SELECT 1 FROM subs)
+			RETURNING id
+		 )
+		 INSERT INTO webhook_deliveries (event_id, subscription_id)
+		 SELECT ev.id, subs.id FROM ev, subs`,
+		eventType, payload,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка записи события %s: %w", eventType, err)
+	}
+
+	return nil
+}
+
+func (db *Database) CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	created, err := scanWebhookSubscription(db.conn(ctx).QueryRow(
+		ctx,
+		`INSERT INTO webhook_subscriptions (url, secret, event_types, created_by)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING `+webhookSubscriptionColumns,
+		sub.URL, sub.Secret, sub.EventTypes, sub.CreatedBy,
+	))
+	if err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("ошибка сохранения подписки: %w", err)
+	}
+
+	return created, nil
+}
+
+func (db *Database) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subs := make([]models.WebhookSubscription, 0)
+
+	err := db.ExecuteQuery(
+		ctx,
+		`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY created_at, id`,
+		nil,
+		func(rows pgx.Rows) error {
+			sub, err := scanWebhookSubscription(rows)
+			if err != nil {
+				return err
+			}
+			subs = append(subs, sub)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (db *Database) DeleteWebhookSubscription(ctx context.Context, id string) (models.WebhookSubscription, error) {
+	sub, err := scanWebhookSubscription(db.conn(ctx).QueryRow(
+		ctx,
+		`DELETE FROM webhook_subscriptions WHERE id = $1 RETURNING `+webhookSubscriptionColumns,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.WebhookSubscription{}, ErrNotFound
+		}
+		return models.WebhookSubscription{}, fmt.Errorf("ошибка удаления подписки: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (db *Database) ListWebhookDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.SubscriptionID != "" {
+		where("d.subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		where("d.status = ?", filter.Status)
+	}
+	if filter.Cursor > 0 {
+		where("d.id < ?", filter.Cursor)
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries d INNER JOIN webhook_events e ON e.id = d.event_id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += " ORDER BY d.id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	deliveries := make([]models.WebhookDelivery, 0, filter.Limit)
+	err := db.ExecuteQuery(ctx, query, args, func(rows pgx.Rows) error {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (db *Database) RedeliverWebhook(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(db.conn(ctx).QueryRow(
+		ctx,
+		`WITH d AS (
+			UPDATE webhook_deliveries
+			SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+			WHERE id = $1
+			RETURNING *
+		 )
+		 SELECT `+webhookDeliveryColumns+` FROM d INNER JOIN webhook_events e ON e.id = d.event_id`,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.WebhookDelivery{}, ErrNotFound
+		}
+		return models.WebhookDelivery{}, fmt.Errorf("ошибка повторной отправки: %w", err)
+	}
+
+	return d, nil
+}
+
+// ClaimWebhookDeliveries забирает готовые к отправке доставки и сдвигает их
+// next_attempt_at на lease, чтобы упавший диспетчер не потерял их навсегда.
+func (db *Database) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingWebhook, error) {
+	pending := make([]models.PendingWebhook, 0, limit)
+
+	err := db.ExecuteQuery(
+		ctx,
+		`WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = NOW() + $2::float8 * INTERVAL '1 millisecond'
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, event_id, subscription_id, attempts
+		 )
+		 SELECT c.id, c.attempts, s.url, s.secret, e.id, e.type, e.created_at, e.payload
+		 FROM claimed c
+		 INNER JOIN webhook_events e ON e.id = c.event_id
+		 INNER JOIN webhook_subscriptions s ON s.id = c.subscription_id
+		 ORDER BY c.id`,
+		[]any{limit, lease.Milliseconds()},
+		func(rows pgx.Rows) error {
+			var p models.PendingWebhook
+			if err := rows.Scan(&p.DeliveryID, &p.Attempts, &p.URL, &p.Secret,
+				&p.Event.ID, &p.Event.Type, &p.Event.OccurredAt, &p.Event.Data); err != nil {
+				return err
+			}
+			pending = append(pending, p)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+func (db *Database) RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt models.WebhookAttempt) error {
+	status := models.DeliveryPending
+	switch {
+	case attempt.Delivered:
+		status = models.DeliveryDelivered
+	case attempt.RetryAfter <= 0:
+		status = models.DeliveryFailed
+	}
+
+	var statusCode *int
+	if attempt.StatusCode > 0 {
+		statusCode = &attempt.StatusCode
+	}
+	var lastError *string
+	if attempt.Error != "" {
+		lastError = &attempt.Error
+	}
+
+	_, err := db.conn(ctx).Exec(
+		ctx,
+		`UPDATE webhook_deliveries
+		 SET status = $2,
+		     attempts = attempts + 1,
+		     last_attempt_at = NOW(),
+		     last_status_code = $3,
+		     last_error = $4,
+		     next_attempt_at = NOW() + $5::float8 * INTERVAL '1 millisecond',
+		     delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END
+		 WHERE id = $1`,
+		deliveryID, status, statusCode, lastError, attempt.RetryAfter.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения результата доставки: %w", err)
+	}
+
+	return nil
+}
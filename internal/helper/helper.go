@@ -3,6 +3,7 @@ package helper
 import (
 	"github.com/Parnishkaspb/avito/internal/models"
 	"math/rand"
+	"sort"
 	"time"
 )
 
@@ -42,3 +43,24 @@ func PickRandomTeamMates(all []string, n int) []string {
 
 	return picked
 }
+
+func PickLeastLoadedTeamMates(all []string, load map[string]int, n int) []string {
+	if n <= 0 || len(all) == 0 {
+		return nil
+	}
+
+	shuffled := make([]string, len(all))
+	for i, idx := range rand.Perm(len(all)) {
+		shuffled[i] = all[idx]
+	}
+
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return load[shuffled[i]] < load[shuffled[j]]
+	})
+
+	if len(shuffled) > n {
+		shuffled = shuffled[:n]
+	}
+
+	return shuffled
+}
@@ -3,6 +3,7 @@ package helper
 import (
 	"github.com/Parnishkaspb/avito/internal/models"
 	"reflect"
+	"sort"
 	"testing"
 )
 
@@ -53,3 +54,25 @@ func TestParseMembers_Negative(t *testing.T) {
 		})
 	}
 }
+
+func TestPickLeastLoadedTeamMates(t *testing.T) {
+	all := []string{"u1", "u2", "u3", "u4"}
+	load := map[string]int{"u1": 5, "u2": 0, "u3": 2}
+
+	for i := 0; i < 20; i++ {
+		got := PickLeastLoadedTeamMates(all, load, 2)
+		sort.Strings(got)
+
+		if !reflect.DeepEqual(got, []string{"u2", "u4"}) {
+			t.Fatalf("PickLeastLoadedTeamMates() = %v, want [u2 u4]", got)
+		}
+	}
+
+	if got := PickLeastLoadedTeamMates(all, load, 10); len(got) != len(all) {
+		t.Errorf("PickLeastLoadedTeamMates() returned %d reviewers, want %d", len(got), len(all))
+	}
+
+	if got := PickLeastLoadedTeamMates(nil, load, 2); got != nil {
+		t.Errorf("PickLeastLoadedTeamMates() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,108 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	English = "en"
+	Russian = "ru"
+)
+
+type Catalog struct {
+	defaultLanguage string
+}
+
+func New(defaultLanguage string) *Catalog {
+	if !Supported(defaultLanguage) {
+		defaultLanguage = English
+	}
+
+	return &Catalog{defaultLanguage: defaultLanguage}
+}
+
+func Supported(lang string) bool {
+	_, ok := messages[lang]
+	return ok
+}
+
+func (c *Catalog) DefaultLanguage() string {
+	return c.defaultLanguage
+}
+
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang   string
+		weight float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		weight := 1.0
+		for _, param := range fields[1:] {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && name == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					weight = q
+				}
+			}
+		}
+
+		if weight <= 0 {
+			continue
+		}
+
+		lang, _, _ := strings.Cut(tag, "-")
+		candidates = append(candidates, candidate{lang: lang, weight: weight})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].weight > candidates[j].weight
+	})
+
+	for _, cand := range candidates {
+		if Supported(cand.lang) {
+			return cand.lang
+		}
+	}
+
+	return c.defaultLanguage
+}
+
+func (c *Catalog) Translate(lang, key string, params map[string]string) string {
+	if !Supported(lang) {
+		lang = c.defaultLanguage
+	}
+
+	return Translate(lang, key, params)
+}
+
+func Translate(lang, key string, params map[string]string) string {
+	template, ok := messages[lang][key]
+	if !ok {
+		template, ok = messages[English][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(params) == 0 {
+		return template
+	}
+
+	// один проход: подставленное значение, похожее на {param}, повторно не заменяется
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+
+	return strings.NewReplacer(pairs...).Replace(template)
+}
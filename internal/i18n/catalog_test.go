@@ -0,0 +1,53 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_Negotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		defaultLang    string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "empty header", defaultLang: Russian, acceptLanguage: "", want: Russian},
+		{name: "exact match", defaultLang: English, acceptLanguage: "ru", want: Russian},
+		{name: "region subtag", defaultLang: English, acceptLanguage: "ru-RU", want: Russian},
+		{name: "quality ordering", defaultLang: Russian, acceptLanguage: "ru;q=0.5, en;q=0.9", want: English},
+		{name: "unsupported only", defaultLang: English, acceptLanguage: "de, fr;q=0.8", want: English},
+		{name: "zero quality ignored", defaultLang: English, acceptLanguage: "ru;q=0", want: English},
+		{name: "unknown default", defaultLang: "de", acceptLanguage: "", want: English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, New(tt.defaultLang).Negotiate(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	assert.Equal(t, "author_id is required", Translate(English, "validation.required", map[string]string{"field": "author_id"}))
+	assert.Equal(t, "author_id обязателен", Translate(Russian, "validation.required", map[string]string{"field": "author_id"}))
+	assert.Equal(t, "unknown.key", Translate(Russian, "unknown.key", nil))
+}
+
+func TestTranslate_ParamsSubstitutedOnce(t *testing.T) {
+	params := map[string]string{"field": "{max}", "max": "10"}
+
+	for range 20 {
+		assert.Equal(t, "{max} must be at most 10 characters", Translate(English, "validation.too_long", params))
+	}
+}
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for key := range messages[English] {
+		for lang := range messages {
+			_, ok := messages[lang][key]
+			assert.True(t, ok, "%s is missing in %s catalog", key, lang)
+		}
+	}
+}
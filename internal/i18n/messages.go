@@ -0,0 +1,110 @@
+package i18n
+
+var messages = map[string]map[string]string{
+	English: {
+		"TEAM_EXISTS":             "team_name already exists",
+		"PR_EXISTS":               "PR id already exists",
+		"PR_MERGED":               "cannot reassign on merged PR",
+		"PR_CLOSED":               "PR is closed",
+		"NOT_ASSIGNED":            "reviewer is not assigned to this PR",
+		"NO_CANDIDATE":            "no active replacement candidate in team",
+		"NOT_FOUND":               "resource not found",
+		"VALIDATION_ERROR":        "request is invalid",
+		"UNAUTHORIZED":            "authentication required",
+		"FORBIDDEN":               "access denied",
+		"METHOD_NOT_ALLOWED":      "method not allowed",
+		"INTERNAL_ERROR":          "internal server error",
+		"PAYLOAD_TOO_LARGE":       "request body is too large",
+		"REQUEST_TIMEOUT":         "request took too long to process",
+		"RATE_LIMITED":            "too many requests, try again later",
+		"IDEMPOTENCY_KEY_REUSED":  "idempotency key was already used with a different request",
+		"IDEMPOTENCY_IN_PROGRESS": "request with this idempotency key is still in progress",
+
+		"auth.invalid_format":        "invalid authorization format",
+		"auth.invalid_token":         "invalid token",
+		"auth.admin_required":        "admin token required",
+		"auth.org_admin_required":    "organization admin permission required",
+		"auth.invalid_api_key":       "invalid, expired or revoked API key",
+		"auth.scope_required":        "API key has no access to this endpoint",
+		"auth.sso_failed":            "single sign-on failed",
+		"auth.sso_invalid_state":     "single sign-on session is unknown or expired, start the login again",
+		"auth.sso_unknown_user":      "no user is linked to this identity",
+		"auth.sso_inactive_user":     "the user linked to this identity is deactivated",
+		"auth.invalid_signature":     "webhook signature is missing or invalid",
+		"auth.invalid_webhook_token": "webhook token is missing or invalid",
+
+		"validation.required":            "{field} is required",
+		"validation.too_long":            "{field} must be at most {max} characters",
+		"validation.invalid_format":      "{field} has invalid format",
+		"validation.invalid_type":        "field {field} has invalid type",
+		"validation.unknown_field":       "unknown field {field}",
+		"validation.empty_body":          "request body is empty",
+		"validation.invalid_json":        "request body is not valid JSON",
+		"validation.invalid_body":        "request body is invalid",
+		"validation.single_object":       "request body must contain a single JSON object",
+		"validation.empty_list":          "{field} must not be empty",
+		"validation.duplicate_member":    "{field} contains duplicate value {value}",
+		"validation.unknown_scope":       "{field} contains unknown scope {value}",
+		"validation.unknown_event":       "{field} contains unknown event type {value}",
+		"validation.in_past":             "{field} must be in the future",
+		"validation.out_of_range":        "{field} must be between {min} and {max}",
+		"validation.too_many":            "{field} must contain at most {max} items",
+		"validation.invalid_codeowners":  "{field} has invalid CODEOWNERS syntax at line {line}",
+		"validation.not_service_account": "service_account is a regular user, API keys can only act as service accounts",
+		"validation.external_id_taken":   "external_id is already mapped to another login",
+		"validation.oidc_subject_taken":  "oidc_subject is already linked to another user",
+	},
+	Russian: {
+		"TEAM_EXISTS":             "команда с таким team_name уже существует",
+		"PR_EXISTS":               "PR с таким id уже существует",
+		"PR_MERGED":               "нельзя переназначить ревьювера в слитом PR",
+		"PR_CLOSED":               "PR закрыт без слияния",
+		"NOT_ASSIGNED":            "пользователь не назначен ревьювером этого PR",
+		"NO_CANDIDATE":            "в команде нет активного кандидата на замену",
+		"NOT_FOUND":               "ресурс не найден",
+		"VALIDATION_ERROR":        "некорректный запрос",
+		"UNAUTHORIZED":            "требуется аутентификация",
+		"FORBIDDEN":               "доступ запрещён",
+		"METHOD_NOT_ALLOWED":      "метод не поддерживается",
+		"INTERNAL_ERROR":          "внутренняя ошибка сервера",
+		"PAYLOAD_TOO_LARGE":       "слишком большое тело запроса",
+		"REQUEST_TIMEOUT":         "превышено время обработки запроса",
+		"RATE_LIMITED":            "слишком много запросов, повторите позже",
+		"IDEMPOTENCY_KEY_REUSED":  "ключ идемпотентности уже использован с другим запросом",
+		"IDEMPOTENCY_IN_PROGRESS": "запрос с этим ключом идемпотентности ещё выполняется",
+
+		"auth.invalid_format":        "неверный формат авторизации",
+		"auth.invalid_token":         "недействительный токен",
+		"auth.admin_required":        "требуется токен администратора",
+		"auth.org_admin_required":    "требуются права администратора организации",
+		"auth.invalid_api_key":       "API-ключ недействителен, истёк или отозван",
+		"auth.scope_required":        "у API-ключа нет доступа к этой ручке",
+		"auth.sso_failed":            "ошибка входа через SSO",
+		"auth.sso_invalid_state":     "сессия входа через SSO не найдена или истекла, начните вход заново",
+		"auth.sso_unknown_user":      "к этой учётной записи не привязан пользователь",
+		"auth.sso_inactive_user":     "пользователь, привязанный к этой учётной записи, деактивирован",
+		"auth.invalid_signature":     "подпись вебхука отсутствует или неверна",
+		"auth.invalid_webhook_token": "токен вебхука отсутствует или неверен",
+
+		"validation.required":            "{field} обязателен",
+		"validation.too_long":            "{field} должен содержать не более {max} символов",
+		"validation.invalid_format":      "{field} имеет неверный формат",
+		"validation.invalid_type":        "поле {field} имеет неверный тип",
+		"validation.unknown_field":       "неизвестное поле {field}",
+		"validation.empty_body":          "тело запроса пустое",
+		"validation.invalid_json":        "тело запроса не является корректным JSON",
+		"validation.invalid_body":        "некорректное тело запроса",
+		"validation.single_object":       "тело запроса должно содержать один JSON-объект",
+		"validation.empty_list":          "{field} не может быть пустым",
+		"validation.duplicate_member":    "{field} содержит повторяющееся значение {value}",
+		"validation.unknown_scope":       "{field} содержит неизвестный scope {value}",
+		"validation.unknown_event":       "{field} содержит неизвестный тип события {value}",
+		"validation.in_past":             "{field} должен быть в будущем",
+		"validation.out_of_range":        "{field} должен быть от {min} до {max}",
+		"validation.too_many":            "{field} должен содержать не более {max} элементов",
+		"validation.invalid_codeowners":  "{field} содержит ошибку синтаксиса CODEOWNERS в строке {line}",
+		"validation.not_service_account": "service_account — обычный пользователь, API-ключ может действовать только от имени сервисного аккаунта",
+		"validation.external_id_taken":   "external_id уже сопоставлен другому логину",
+		"validation.oidc_subject_taken":  "oidc_subject уже привязан к другому пользователю",
+	},
+}
@@ -0,0 +1,226 @@
+// Package fakehost is an in-process code host for tests. It serves the GitHub
+// requested_reviewers endpoints and the GitLab merge request and user lookup
+// endpoints (under /api/v4), and keeps the reviewers of every pull request in
+// memory so tests can assert on what the client changed.
+package fakehost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type Server struct {
+	URL   string
+	Token string
+
+	mu        sync.Mutex
+	users     map[string]int64
+	reviewers map[string][]string
+	failures  []int
+	requests  []string
+}
+
+func New(t testing.TB, token string) *Server {
+	t.Helper()
+
+	s := &Server{
+		Token:     token,
+		users:     make(map[string]int64),
+		reviewers: make(map[string][]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/{owner}/{repo}/pulls/{number}/requested_reviewers", s.githubRequestReviewers)
+	mux.HandleFunc("DELETE /repos/{owner}/{repo}/pulls/{number}/requested_reviewers", s.githubRequestReviewers)
+	mux.HandleFunc("GET /api/v4/users", s.gitlabUsers)
+	mux.HandleFunc("GET /api/v4/projects/{project}/merge_requests/{iid}", s.gitlabMergeRequest)
+	mux.HandleFunc("PUT /api/v4/projects/{project}/merge_requests/{iid}", s.gitlabMergeRequest)
+
+	srv := httptest.NewServer(s.intercept(mux))
+	t.Cleanup(srv.Close)
+
+	s.URL = srv.URL
+	return s
+}
+
+// AddUser registers a login that may be requested as a reviewer. On GitLab the
+// user gets id; GitHub only checks that the login is known.
+func (s *Server) AddUser(login string, id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[login] = id
+}
+
+// SetReviewers sets the reviewers of a pull request, keyed like the service
+// ids without the provider prefix: "owner/repo#42" or "group/project!7".
+func (s *Server) SetReviewers(key string, logins ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reviewers[key] = slices.Clone(logins)
+}
+
+func (s *Server) Reviewers(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := slices.Clone(s.reviewers[key])
+	slices.Sort(out)
+	return out
+}
+
+// FailNext makes the next len(statuses) requests fail with the given statuses.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// Requests returns "METHOD path" of every request received so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) intercept(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.EscapedPath())
+		var status int
+		if len(s.failures) > 0 {
+			status, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			writeJSON(w, status, map[string]string{"message": http.StatusText(status)})
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+s.Token && r.Header.Get("PRIVATE-TOKEN") != s.Token {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) githubRequestReviewers(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Reviewers []string `json:"reviewers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+
+	key := r.PathValue("owner") + "/" + r.PathValue("repo") + "#" + r.PathValue("number")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.reviewers[key]
+	for _, login := range body.Reviewers {
+		if r.Method == http.MethodDelete {
+			current = slices.DeleteFunc(current, func(l string) bool { return strings.EqualFold(l, login) })
+			continue
+		}
+		if _, ok := s.users[login]; !ok {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+				"message": "Reviews may only be requested from collaborators.",
+			})
+			return
+		}
+		if !slices.Contains(current, login) {
+			current = append(current, login)
+		}
+	}
+	s.reviewers[key] = current
+
+	writeJSON(w, http.StatusOK, map[string]any{"requested_reviewers": githubUsers(current)})
+}
+
+func (s *Server) gitlabUsers(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := []map[string]any{}
+	for login, id := range s.users {
+		if strings.EqualFold(login, username) {
+			users = append(users, map[string]any{"id": id, "username": login})
+		}
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) gitlabMergeRequest(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("project") + "!" + r.PathValue("iid")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.reviewers[key]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "404 Not found"})
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var body struct {
+			ReviewerIDs []int64 `json:"reviewer_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": "400 Bad request"})
+			return
+		}
+
+		current = current[:0:0]
+		for _, id := range body.ReviewerIDs {
+			login, ok := s.loginByID(id)
+			if !ok {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"message": "reviewer_ids: unknown user " + strconv.FormatInt(id, 10)})
+				return
+			}
+			current = append(current, login)
+		}
+		s.reviewers[key] = current
+	}
+
+	reviewers := make([]map[string]any, 0, len(current))
+	for _, login := range current {
+		id := s.users[login]
+		reviewers = append(reviewers, map[string]any{"id": id, "username": login})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"iid": r.PathValue("iid"), "reviewers": reviewers})
+}
+
+func (s *Server) loginByID(id int64) (string, bool) {
+	for login, uid := range s.users {
+		if uid == id {
+			return login, true
+		}
+	}
+	return "", false
+}
+
+func githubUsers(logins []string) []map[string]string {
+	out := make([]map[string]string, 0, len(logins))
+	for _, login := range logins {
+		out = append(out, map[string]string{"login": login})
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,106 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const apiVersion = "2022-11-28"
+
+// Client вызывает REST API GitHub для запроса ревью.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewClient(cfg config.GitHubConfig, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(cfg.APIURL, "/"),
+		token:   cfg.APIToken,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// ParsePullRequestID разбирает id вида "github:owner/repo#42", построенный PullRequestID.
+func ParsePullRequestID(prID string) (repository string, number int64, ok bool) {
+	rest, ok := strings.CutPrefix(prID, models.ProviderGitHub+":")
+	if !ok {
+		return "", 0, false
+	}
+
+	i := strings.LastIndex(rest, "#")
+	if i <= 0 || !strings.Contains(rest[:i], "/") {
+		return "", 0, false
+	}
+
+	number, err := strconv.ParseInt(rest[i+1:], 10, 64)
+	if err != nil || number <= 0 {
+		return "", 0, false
+	}
+
+	return rest[:i], number, true
+}
+
+// RequestReviewers снимает запрос ревью с remove и запрашивает его у add.
+// Оба вызова GitHub идемпотентны, поэтому повтор после частичного успеха безопасен.
+func (c *Client) RequestReviewers(ctx context.Context, prID string, add, remove []string) error {
+	repository, number, ok := ParsePullRequestID(prID)
+	if !ok {
+		return fmt.Errorf("%w: %q is not a github pull request id", integrations.ErrNotRetryable, prID)
+	}
+
+	path := fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", repository, number)
+
+	if len(remove) > 0 {
+		if err := c.do(ctx, http.MethodDelete, path, remove); err != nil {
+			return err
+		}
+	}
+	if len(add) > 0 {
+		if err := c.do(ctx, http.MethodPost, path, add); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, reviewers []string) error {
+	body, err := json.Marshal(map[string][]string{"reviewers": reviewers})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := integrations.CheckResponse(models.ProviderGitHub, resp); err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
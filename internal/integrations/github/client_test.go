@@ -0,0 +1,80 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/integrations/fakehost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T) (*Client, *fakehost.Server) {
+	t.Helper()
+
+	host := fakehost.New(t, "ghp-test")
+	host.AddUser("octocat", 1)
+	host.AddUser("hubot", 2)
+	host.AddUser("monalisa", 3)
+
+	return NewClient(config.GitHubConfig{APIURL: host.URL, APIToken: "ghp-test"}, time.Second), host
+}
+
+func TestParsePullRequestID(t *testing.T) {
+	repo, number, ok := ParsePullRequestID(PullRequestID("avito-tech/review-service", 42))
+	require.True(t, ok)
+	assert.Equal(t, "avito-tech/review-service", repo)
+	assert.Equal(t, int64(42), number)
+
+	for _, id := range []string{"pr-1", "gitlab:a/b!1", "github:repo#1", "github:a/b#x", "github:a/b#0"} {
+		_, _, ok := ParsePullRequestID(id)
+		assert.False(t, ok, id)
+	}
+}
+
+func TestClient_RequestReviewers(t *testing.T) {
+	c, host := newTestClient(t)
+	ctx := context.Background()
+	prID := PullRequestID("acme/api", 7)
+
+	require.NoError(t, c.RequestReviewers(ctx, prID, []string{"octocat", "hubot"}, nil))
+	assert.Equal(t, []string{"hubot", "octocat"}, host.Reviewers("acme/api#7"))
+
+	// повтор после переназначения: снятый ревьюер уходит, оставшийся не дублируется
+	require.NoError(t, c.RequestReviewers(ctx, prID, []string{"octocat", "monalisa"}, []string{"hubot"}))
+	assert.Equal(t, []string{"monalisa", "octocat"}, host.Reviewers("acme/api#7"))
+
+	assert.Equal(t, []string{
+		"POST /repos/acme/api/pulls/7/requested_reviewers",
+		"DELETE /repos/acme/api/pulls/7/requested_reviewers",
+		"POST /repos/acme/api/pulls/7/requested_reviewers",
+	}, host.Requests())
+}
+
+func TestClient_Errors(t *testing.T) {
+	c, host := newTestClient(t)
+	ctx := context.Background()
+	prID := PullRequestID("acme/api", 7)
+
+	err := c.RequestReviewers(ctx, prID, []string{"stranger"}, nil)
+	assert.ErrorIs(t, err, integrations.ErrNotRetryable)
+
+	host.FailNext(http.StatusBadGateway)
+	err = c.RequestReviewers(ctx, prID, []string{"octocat"}, nil)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, integrations.ErrNotRetryable)
+
+	host.FailNext(http.StatusTooManyRequests)
+	err = c.RequestReviewers(ctx, prID, []string{"octocat"}, nil)
+	assert.NotErrorIs(t, err, integrations.ErrNotRetryable)
+
+	bad := NewClient(config.GitHubConfig{APIURL: host.URL, APIToken: "wrong"}, time.Second)
+	assert.ErrorIs(t, bad.RequestReviewers(ctx, prID, []string{"octocat"}, nil), integrations.ErrNotRetryable)
+
+	assert.ErrorIs(t, c.RequestReviewers(ctx, "pr-1", []string{"octocat"}, nil), integrations.ErrNotRetryable)
+	assert.Empty(t, host.Reviewers("acme/api#7"))
+}
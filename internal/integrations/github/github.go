@@ -0,0 +1,111 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const (
+	HeaderEvent     = "X-GitHub-Event"
+	HeaderDelivery  = "X-GitHub-Delivery"
+	HeaderSignature = "X-Hub-Signature-256"
+
+	EventPullRequest = "pull_request"
+	EventPing        = "ping"
+)
+
+var (
+	ErrInvalidSignature = errors.New("github: invalid webhook signature")
+	ErrInvalidPayload   = errors.New("github: invalid webhook payload")
+)
+
+type pullRequestPayload struct {
+	Action      string `json:"action"`
+	Number      int64  `json:"number"`
+	PullRequest struct {
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// VerifySignature проверяет заголовок X-Hub-Signature-256: HMAC-SHA256 тела запроса с секретом вебхука.
+func VerifySignature(secret string, body []byte, header string) error {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func PullRequestID(repository string, number int64) string {
+	return fmt.Sprintf("%s:%s#%d", models.ProviderGitHub, repository, number)
+}
+
+func ParsePullRequestEvent(deliveryID string, body []byte) (integrations.Event, error) {
+	var payload pullRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return integrations.Event{}, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	if payload.Number <= 0 || payload.Repository.FullName == "" {
+		return integrations.Event{}, fmt.Errorf("%w: missing pull request number or repository", ErrInvalidPayload)
+	}
+
+	ev := integrations.Event{
+		Provider:      models.ProviderGitHub,
+		DeliveryID:    deliveryID,
+		PullRequestID: PullRequestID(payload.Repository.FullName, payload.Number),
+		Title:         payload.PullRequest.Title,
+		AuthorLogin:   payload.PullRequest.User.Login,
+	}
+
+	switch payload.Action {
+	case "opened", "reopened":
+		if payload.PullRequest.Draft {
+			ev.Action, ev.Reason = integrations.ActionIgnore, "draft"
+			break
+		}
+		ev.Action = integrations.ActionOpen
+		if payload.Action == "reopened" {
+			ev.Action = integrations.ActionReopen
+		}
+	case "ready_for_review":
+		ev.Action = integrations.ActionReopen
+	case "closed":
+		ev.Action = integrations.ActionClose
+		if payload.PullRequest.Merged {
+			ev.Action = integrations.ActionMerge
+		}
+	default:
+		ev.Action, ev.Reason = integrations.ActionIgnore, "unsupported_action"
+	}
+
+	return ev, nil
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return body
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := fixture(t, "pull_request_opened.json")
+
+	assert.NoError(t, VerifySignature("s3cret", body, sign("s3cret", body)))
+	assert.ErrorIs(t, VerifySignature("other", body, sign("s3cret", body)), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature("s3cret", body, ""), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature("s3cret", body, "sha256=zz"), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature("s3cret", append(body, ' '), sign("s3cret", body)), ErrInvalidSignature)
+}
+
+func TestParsePullRequestEvent(t *testing.T) {
+	tests := []struct {
+		fixture string
+		action  string
+		prID    string
+		reason  string
+	}{
+		{"pull_request_opened.json", integrations.ActionOpen, "github:avito-tech/review-service#42", ""},
+		{"pull_request_opened_draft.json", integrations.ActionIgnore, "github:avito-tech/review-service#43", "draft"},
+		{"pull_request_ready_for_review.json", integrations.ActionReopen, "github:avito-tech/review-service#43", ""},
+		{"pull_request_closed.json", integrations.ActionClose, "github:avito-tech/review-service#42", ""},
+		{"pull_request_reopened.json", integrations.ActionReopen, "github:avito-tech/review-service#42", ""},
+		{"pull_request_merged.json", integrations.ActionMerge, "github:avito-tech/review-service#42", ""},
+		{"pull_request_labeled.json", integrations.ActionIgnore, "github:avito-tech/review-service#42", "unsupported_action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			ev, err := ParsePullRequestEvent("delivery-1", fixture(t, tt.fixture))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.action, ev.Action)
+			assert.Equal(t, tt.prID, ev.PullRequestID)
+			assert.Equal(t, tt.reason, ev.Reason)
+			assert.Equal(t, "github", ev.Provider)
+			assert.Equal(t, "delivery-1", ev.DeliveryID)
+			assert.Equal(t, "octocat", ev.AuthorLogin)
+			assert.Equal(t, "Add search by team", ev.Title)
+		})
+	}
+}
+
+func TestParsePullRequestEvent_InvalidPayload(t *testing.T) {
+	for _, body := range []string{`not json`, `{"action":"opened"}`, `{"action":"opened","number":1}`} {
+		_, err := ParsePullRequestEvent("d", []byte(body))
+		assert.ErrorIs(t, err, ErrInvalidPayload, body)
+	}
+}
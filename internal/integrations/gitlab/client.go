@@ -0,0 +1,162 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const maxResponseBytes = 1 << 20
+
+// Client вызывает REST API GitLab (v4) для назначения ревьюеров MR.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+type user struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+func NewClient(cfg config.GitLabConfig, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(cfg.APIURL, "/"),
+		token:   cfg.APIToken,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// ParsePullRequestID разбирает id вида "gitlab:15!7", построенный PullRequestID.
+func ParsePullRequestID(prID string) (projectID, iid int64, ok bool) {
+	rest, ok := strings.CutPrefix(prID, models.ProviderGitLab+":")
+	if !ok {
+		return 0, 0, false
+	}
+
+	project, mr, ok := strings.Cut(rest, "!")
+	if !ok {
+		return 0, 0, false
+	}
+
+	projectID, err := strconv.ParseInt(project, 10, 64)
+	if err != nil || projectID <= 0 {
+		return 0, 0, false
+	}
+
+	iid, err = strconv.ParseInt(mr, 10, 64)
+	if err != nil || iid <= 0 {
+		return 0, 0, false
+	}
+
+	return projectID, iid, true
+}
+
+// RequestReviewers приводит список ревьюеров MR к текущему минус remove плюс add.
+// GitLab принимает только полный список reviewer_ids, поэтому ревьюеры, добавленные
+// в GitLab вручную, сохраняются.
+func (c *Client) RequestReviewers(ctx context.Context, prID string, add, remove []string) error {
+	projectID, iid, ok := ParsePullRequestID(prID)
+	if !ok {
+		return fmt.Errorf("%w: %q is not a gitlab merge request id", integrations.ErrNotRetryable, prID)
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	path := fmt.Sprintf("/projects/%d/merge_requests/%d", projectID, iid)
+
+	var mr struct {
+		Reviewers []user `json:"reviewers"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &mr); err != nil {
+		return err
+	}
+
+	current := make([]int64, 0, len(mr.Reviewers))
+	for _, r := range mr.Reviewers {
+		if !slices.ContainsFunc(remove, func(login string) bool { return strings.EqualFold(login, r.Username) }) {
+			current = append(current, r.ID)
+		}
+	}
+
+	reviewerIDs := slices.Clone(current)
+	for _, login := range add {
+		id, err := c.userID(ctx, login)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(reviewerIDs, id) {
+			reviewerIDs = append(reviewerIDs, id)
+		}
+	}
+
+	if len(reviewerIDs) == len(mr.Reviewers) && len(current) == len(mr.Reviewers) {
+		return nil
+	}
+
+	return c.do(ctx, http.MethodPut, path, map[string][]int64{"reviewer_ids": reviewerIDs}, nil)
+}
+
+func (c *Client) userID(ctx context.Context, username string) (int64, error) {
+	var users []user
+	if err := c.do(ctx, http.MethodGet, "/users?username="+url.QueryEscape(username), nil, &users); err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("%w: gitlab user %q not found", integrations.ErrNotRetryable, username)
+	}
+	return users[0].ID, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := integrations.CheckResponse(models.ProviderGitLab, resp); err != nil {
+		return err
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out); err != nil {
+		return fmt.Errorf("gitlab: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/integrations/fakehost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T) (*Client, *fakehost.Server) {
+	t.Helper()
+
+	host := fakehost.New(t, "glpat-test")
+	host.AddUser("jdoe", 101)
+	host.AddUser("asmith", 102)
+	host.AddUser("bwayne", 103)
+	host.AddUser("manual", 104)
+
+	return NewClient(config.GitLabConfig{APIURL: host.URL + "/api/v4", APIToken: "glpat-test"}, time.Second), host
+}
+
+func TestParsePullRequestID(t *testing.T) {
+	projectID, iid, ok := ParsePullRequestID(PullRequestID(15, 7))
+	require.True(t, ok)
+	assert.Equal(t, int64(15), projectID)
+	assert.Equal(t, int64(7), iid)
+
+	for _, id := range []string{"pr-1", "github:a/b#1", "gitlab:!1", "gitlab:a/b!1", "gitlab:platform/backend/review-service!7", "gitlab:15!x", "gitlab:15!-1", "gitlab:0!1", "gitlab:15!7!1"} {
+		_, _, ok := ParsePullRequestID(id)
+		assert.False(t, ok, id)
+	}
+}
+
+func TestClient_RequestReviewers(t *testing.T) {
+	c, host := newTestClient(t)
+	ctx := context.Background()
+	prID := PullRequestID(15, 7)
+	host.SetReviewers("15!7", "manual")
+
+	require.NoError(t, c.RequestReviewers(ctx, prID, []string{"asmith", "bwayne"}, nil))
+	assert.Equal(t, []string{"asmith", "bwayne", "manual"}, host.Reviewers("15!7"))
+
+	require.NoError(t, c.RequestReviewers(ctx, prID, []string{"asmith", "jdoe"}, []string{"bwayne"}))
+	assert.Equal(t, []string{"asmith", "jdoe", "manual"}, host.Reviewers("15!7"))
+
+	// состояние уже совпадает — PUT не нужен
+	before := len(host.Requests())
+	require.NoError(t, c.RequestReviewers(ctx, prID, []string{"asmith", "jdoe"}, []string{"bwayne"}))
+	requests := host.Requests()[before:]
+	assert.NotContains(t, requests, "PUT /api/v4/projects/15/merge_requests/7")
+	assert.Contains(t, requests, "GET /api/v4/projects/15/merge_requests/7")
+}
+
+func TestClient_Errors(t *testing.T) {
+	c, host := newTestClient(t)
+	ctx := context.Background()
+	prID := PullRequestID(15, 7)
+
+	assert.ErrorIs(t, c.RequestReviewers(ctx, "gitlab:a/b!1", []string{"asmith"}, nil), integrations.ErrNotRetryable)
+	assert.Empty(t, host.Requests())
+
+	assert.ErrorIs(t, c.RequestReviewers(ctx, prID, []string{"asmith"}, nil), integrations.ErrNotRetryable)
+
+	host.SetReviewers("15!7")
+	assert.ErrorIs(t, c.RequestReviewers(ctx, prID, []string{"stranger"}, nil), integrations.ErrNotRetryable)
+
+	host.FailNext(http.StatusServiceUnavailable)
+	err := c.RequestReviewers(ctx, prID, []string{"asmith"}, nil)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, integrations.ErrNotRetryable)
+	assert.Empty(t, host.Reviewers("15!7"))
+
+	require.NoError(t, c.RequestReviewers(ctx, prID, []string{"asmith"}, nil))
+	assert.Equal(t, []string{"asmith"}, host.Reviewers("15!7"))
+}
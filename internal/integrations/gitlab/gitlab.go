@@ -0,0 +1,144 @@
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const (
+	HeaderEvent          = "X-Gitlab-Event"
+	HeaderToken          = "X-Gitlab-Token"
+	HeaderEventUUID      = "X-Gitlab-Event-UUID"
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	EventMergeRequest = "Merge Request Hook"
+)
+
+var (
+	ErrInvalidToken   = errors.New("gitlab: invalid webhook token")
+	ErrInvalidPayload = errors.New("gitlab: invalid webhook payload")
+)
+
+type draftChange struct {
+	Previous bool `json:"previous"`
+	Current  bool `json:"current"`
+}
+
+type mergeRequestPayload struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		ID int64 `json:"id"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID            int64  `json:"iid"`
+		Title          string `json:"title"`
+		AuthorID       int64  `json:"author_id"`
+		State          string `json:"state"`
+		Action         string `json:"action"`
+		Draft          bool   `json:"draft"`
+		WorkInProgress bool   `json:"work_in_progress"`
+	} `json:"object_attributes"`
+	// Старые версии GitLab присылают work_in_progress вместо draft.
+	Changes struct {
+		Draft          *draftChange `json:"draft"`
+		WorkInProgress *draftChange `json:"work_in_progress"`
+	} `json:"changes"`
+}
+
+// VerifyToken сравнивает X-Gitlab-Token с секретом вебхука за постоянное время.
+func VerifyToken(secret, header string) error {
+	if header == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(header)) != 1 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// DeliveryID возвращает Idempotency-Key, который GitLab сохраняет при повторах, а для
+// версий без него — X-Gitlab-Event-UUID.
+func DeliveryID(h http.Header) string {
+	if key := h.Get(HeaderIdempotencyKey); key != "" {
+		return key
+	}
+	return h.Get(HeaderEventUUID)
+}
+
+// PullRequestID строит id PR из числового id проекта и iid: iid уникален только внутри проекта,
+// а путь проекта меняется при переименовании и переносе между группами.
+func PullRequestID(projectID, iid int64) string {
+	return fmt.Sprintf("%s:%d!%d", models.ProviderGitLab, projectID, iid)
+}
+
+func ParseMergeRequestEvent(deliveryID string, body []byte) (integrations.Event, error) {
+	var payload mergeRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return integrations.Event{}, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	attrs := payload.ObjectAttributes
+	if payload.ObjectKind != "merge_request" || attrs.IID <= 0 || payload.Project.ID <= 0 {
+		return integrations.Event{}, fmt.Errorf("%w: missing merge request iid or project", ErrInvalidPayload)
+	}
+
+	ev := integrations.Event{
+		Provider:      models.ProviderGitLab,
+		DeliveryID:    deliveryID,
+		PullRequestID: PullRequestID(payload.Project.ID, attrs.IID),
+		Title:         attrs.Title,
+	}
+	// В хуке есть только author_id, логин известен лишь когда событие вызвал сам автор;
+	// тогда сопоставление запоминает author_id и находит автора в событиях от других пользователей.
+	if attrs.AuthorID > 0 {
+		ev.AuthorExternalID = strconv.FormatInt(attrs.AuthorID, 10)
+	}
+	if payload.User.ID == attrs.AuthorID {
+		ev.AuthorLogin = payload.User.Username
+	}
+
+	draft := attrs.Draft || attrs.WorkInProgress
+
+	switch attrs.Action {
+	case "open", "reopen":
+		if draft {
+			ev.Action, ev.Reason = integrations.ActionIgnore, "draft"
+			break
+		}
+		ev.Action = integrations.ActionOpen
+		if attrs.Action == "reopen" {
+			ev.Action = integrations.ActionReopen
+		}
+	case "update":
+		change := payload.Changes.Draft
+		if change == nil {
+			change = payload.Changes.WorkInProgress
+		}
+		switch {
+		case change == nil:
+			ev.Action, ev.Reason = integrations.ActionIgnore, "unsupported_action"
+		case change.Current:
+			ev.Action, ev.Reason = integrations.ActionIgnore, "draft"
+		case attrs.State != "opened":
+			ev.Action, ev.Reason = integrations.ActionIgnore, "not_opened"
+		default:
+			ev.Action = integrations.ActionReopen
+		}
+	case "merge":
+		ev.Action = integrations.ActionMerge
+	case "close":
+		ev.Action = integrations.ActionClose
+	default:
+		ev.Action, ev.Reason = integrations.ActionIgnore, "unsupported_action"
+	}
+
+	return ev, nil
+}
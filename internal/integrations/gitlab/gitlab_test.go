@@ -0,0 +1,93 @@
+package gitlab
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return body
+}
+
+func TestVerifyToken(t *testing.T) {
+	assert.NoError(t, VerifyToken("s3cret", "s3cret"))
+	assert.ErrorIs(t, VerifyToken("s3cret", "other"), ErrInvalidToken)
+	assert.ErrorIs(t, VerifyToken("s3cret", "s3cret "), ErrInvalidToken)
+	assert.ErrorIs(t, VerifyToken("s3cret", ""), ErrInvalidToken)
+}
+
+func TestDeliveryID(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderEventUUID, "uuid-1")
+	assert.Equal(t, "uuid-1", DeliveryID(h))
+
+	h.Set(HeaderIdempotencyKey, "key-1")
+	assert.Equal(t, "key-1", DeliveryID(h))
+}
+
+func TestParseMergeRequestEvent(t *testing.T) {
+	tests := []struct {
+		fixture string
+		action  string
+		reason  string
+		author  string
+	}{
+		{"merge_request_open.json", integrations.ActionOpen, "", "jdoe"},
+		{"merge_request_open_draft.json", integrations.ActionIgnore, "draft", "jdoe"},
+		{"merge_request_update_ready.json", integrations.ActionReopen, "", "jdoe"},
+		{"merge_request_update_ready_legacy.json", integrations.ActionReopen, "", "jdoe"},
+		{"merge_request_update_ready_by_maintainer.json", integrations.ActionReopen, "", ""},
+		{"merge_request_update_draft.json", integrations.ActionIgnore, "draft", "jdoe"},
+		{"merge_request_update_labels.json", integrations.ActionIgnore, "unsupported_action", "jdoe"},
+		{"merge_request_close.json", integrations.ActionClose, "", "jdoe"},
+		{"merge_request_reopen.json", integrations.ActionReopen, "", "jdoe"},
+		{"merge_request_merge.json", integrations.ActionMerge, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			ev, err := ParseMergeRequestEvent("delivery-1", fixture(t, tt.fixture))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.action, ev.Action)
+			assert.Equal(t, tt.reason, ev.Reason)
+			assert.Equal(t, tt.author, ev.AuthorLogin)
+			assert.Equal(t, "101", ev.AuthorExternalID)
+			assert.Equal(t, "gitlab:15!7", ev.PullRequestID)
+			assert.Equal(t, "gitlab", ev.Provider)
+			assert.Equal(t, "delivery-1", ev.DeliveryID)
+			assert.Equal(t, "Add search by team", ev.Title)
+		})
+	}
+}
+
+func TestParseMergeRequestEvent_StableAcrossProjectRename(t *testing.T) {
+	body := strings.Replace(string(fixture(t, "merge_request_close.json")), `"platform/review-service"`, `"platform/search/review-service"`, 1)
+
+	ev, err := ParseMergeRequestEvent("delivery-1", []byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, "gitlab:15!7", ev.PullRequestID)
+}
+
+func TestParseMergeRequestEvent_InvalidPayload(t *testing.T) {
+	for _, body := range []string{
+		`not json`,
+		`{"object_kind":"push"}`,
+		`{"object_kind":"merge_request","object_attributes":{"iid":1}}`,
+		`{"object_kind":"merge_request","project":{"path_with_namespace":"a/b"},"object_attributes":{"iid":1}}`,
+	} {
+		_, err := ParseMergeRequestEvent("d", []byte(body))
+		assert.ErrorIs(t, err, ErrInvalidPayload, body)
+	}
+}
@@ -0,0 +1,152 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const (
+	ActionOpen   = "open"
+	ActionClose  = "close"
+	ActionMerge  = "merge"
+	ActionReopen = "reopen"
+	ActionIgnore = "ignore"
+)
+
+const (
+	OutcomeCreated   = "created"
+	OutcomeMerged    = "merged"
+	OutcomeClosed    = "closed"
+	OutcomeReopened  = "reopened"
+	OutcomeUnchanged = "unchanged"
+	OutcomeIgnored   = "ignored"
+)
+
+const maxTitleLength = 255
+
+// Event — событие code host, приведённое к жизненному циклу PR сервиса.
+type Event struct {
+	Provider      string
+	DeliveryID    string
+	Action        string
+	PullRequestID string
+	Title         string
+	AuthorLogin   string
+	// AuthorExternalID — неизменяемый id автора во внешней системе, если логина в событии нет.
+	AuthorExternalID string
+	Reason           string
+}
+
+type Result struct {
+	Outcome     string                      `json:"status"`
+	Reason      string                      `json:"reason,omitempty"`
+	PullRequest *models.PullRequestResponse `json:"pull_request,omitempty"`
+}
+
+type Store interface {
+	ResolveIdentity(ctx context.Context, provider, login, externalID string) (string, error)
+	CreatePullRequestWithReviewers(ctx context.Context, req models.PullRequestCreateRequest, policy models.ReviewerPolicy) (models.PullRequestResponse, error)
+	MergePullRequest(ctx context.Context, prID string) (models.PullRequest, bool, error)
+	ClosePullRequest(ctx context.Context, prID string) (bool, error)
+	ReopenPullRequest(ctx context.Context, prID string) (bool, error)
+}
+
+type Syncer struct {
+	db Store
+}
+
+func NewSyncer(db Store) *Syncer {
+	return &Syncer{db: db}
+}
+
+func (s *Syncer) Apply(ctx context.Context, ev Event, policy models.ReviewerPolicy) (Result, error) {
+	if ev.Action != ActionIgnore {
+		if err := models.ValidateID("pull_request_id", ev.PullRequestID); err != nil {
+			return Result{Outcome: OutcomeIgnored, Reason: "invalid_pull_request_id"}, nil
+		}
+	}
+
+	switch ev.Action {
+	case ActionOpen:
+		return s.open(ctx, ev, policy)
+	case ActionReopen:
+		reopened, err := s.db.ReopenPullRequest(ctx, ev.PullRequestID)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			return s.open(ctx, ev, policy)
+		case errors.Is(err, database.ErrPRMerged):
+			return Result{Outcome: OutcomeIgnored, Reason: "already_merged"}, nil
+		case err != nil:
+			return Result{}, err
+		}
+		return changed(reopened, OutcomeReopened), nil
+	case ActionClose:
+		closed, err := s.db.ClosePullRequest(ctx, ev.PullRequestID)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			return Result{Outcome: OutcomeIgnored, Reason: "unknown_pull_request"}, nil
+		case errors.Is(err, database.ErrPRMerged):
+			return Result{Outcome: OutcomeIgnored, Reason: "already_merged"}, nil
+		case err != nil:
+			return Result{}, err
+		}
+		return changed(closed, OutcomeClosed), nil
+	case ActionMerge:
+		_, merged, err := s.db.MergePullRequest(ctx, ev.PullRequestID)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			return Result{Outcome: OutcomeIgnored, Reason: "unknown_pull_request"}, nil
+		case errors.Is(err, database.ErrPRClosed):
+			return Result{Outcome: OutcomeIgnored, Reason: "closed"}, nil
+		case err != nil:
+			return Result{}, err
+		}
+		return changed(merged, OutcomeMerged), nil
+	default:
+		return Result{Outcome: OutcomeIgnored, Reason: ev.Reason}, nil
+	}
+}
+
+func (s *Syncer) open(ctx context.Context, ev Event, policy models.ReviewerPolicy) (Result, error) {
+	authorID, err := s.db.ResolveIdentity(ctx, ev.Provider, ev.AuthorLogin, ev.AuthorExternalID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return Result{Outcome: OutcomeIgnored, Reason: "unknown_author"}, nil
+		}
+		return Result{}, err
+	}
+
+	pr, err := s.db.CreatePullRequestWithReviewers(ctx, models.PullRequestCreateRequest{
+		PullRequestId:   ev.PullRequestID,
+		PullRequestName: truncate(ev.Title, maxTitleLength),
+		AuthorID:        authorID,
+	}, policy)
+	switch {
+	case errors.Is(err, database.ErrPRExists):
+		return Result{Outcome: OutcomeUnchanged}, nil
+	case errors.Is(err, database.ErrNotFound):
+		return Result{Outcome: OutcomeIgnored, Reason: "author_without_team"}, nil
+	case err != nil:
+		return Result{}, err
+	}
+
+	return Result{Outcome: OutcomeCreated, PullRequest: &pr}, nil
+}
+
+func changed(ok bool, outcome string) Result {
+	if !ok {
+		return Result{Outcome: OutcomeUnchanged}
+	}
+	return Result{Outcome: outcome}
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,186 @@
+package integrations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	identities  map[string]string
+	externalIDs map[string]string
+	status      map[string]string
+	created     []models.PullRequestCreateRequest
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		identities:  map[string]string{"github/octocat": "u1"},
+		externalIDs: map[string]string{},
+		status:      map[string]string{},
+	}
+}
+
+func (f *fakeStore) ResolveIdentity(_ context.Context, provider, login, externalID string) (string, error) {
+	userID, ok := f.identities[provider+"/"+login]
+	if ok && externalID != "" {
+		if _, taken := f.externalIDs[provider+"/"+externalID]; !taken {
+			f.externalIDs[provider+"/"+externalID] = userID
+		}
+	}
+	if byID, found := f.externalIDs[provider+"/"+externalID]; found {
+		return byID, nil
+	}
+	if !ok {
+		return "", database.ErrNotFound
+	}
+	return userID, nil
+}
+
+func (f *fakeStore) CreatePullRequestWithReviewers(_ context.Context, req models.PullRequestCreateRequest, _ models.ReviewerPolicy) (models.PullRequestResponse, error) {
+	if _, ok := f.status[req.PullRequestId]; ok {
+		return models.PullRequestResponse{}, database.ErrPRExists
+	}
+	f.status[req.PullRequestId] = "OPEN"
+	f.created = append(f.created, req)
+	return models.PullRequestResponse{PullRequestID: req.PullRequestId, AuthorID: req.AuthorID, Status: "OPEN"}, nil
+}
+
+func (f *fakeStore) MergePullRequest(_ context.Context, prID string) (models.PullRequest, bool, error) {
+	switch f.status[prID] {
+	case "":
+		return models.PullRequest{}, false, database.ErrNotFound
+	case "CLOSED":
+		return models.PullRequest{}, false, database.ErrPRClosed
+	case "MERGED":
+		return models.PullRequest{}, false, nil
+	}
+	f.status[prID] = "MERGED"
+	return models.PullRequest{}, true, nil
+}
+
+func (f *fakeStore) ClosePullRequest(_ context.Context, prID string) (bool, error) {
+	return f.transition(prID, "OPEN", "CLOSED")
+}
+
+func (f *fakeStore) ReopenPullRequest(_ context.Context, prID string) (bool, error) {
+	return f.transition(prID, "CLOSED", "OPEN")
+}
+
+func (f *fakeStore) transition(prID, from, to string) (bool, error) {
+	switch f.status[prID] {
+	case "":
+		return false, database.ErrNotFound
+	case "MERGED":
+		return false, database.ErrPRMerged
+	case from:
+		f.status[prID] = to
+		return true, nil
+	}
+	return false, nil
+}
+
+func event(action string) Event {
+	return Event{
+		Provider:      "github",
+		Action:        action,
+		PullRequestID: "github:acme/api#7",
+		Title:         "Fix flaky test",
+		AuthorLogin:   "octocat",
+	}
+}
+
+func TestSyncer_Lifecycle(t *testing.T) {
+	db := newFakeStore()
+	s := NewSyncer(db)
+	ctx := context.Background()
+	policy := models.ReviewerPolicy{Count: 2}
+
+	steps := []struct {
+		action  string
+		outcome string
+		status  string
+	}{
+		{ActionOpen, OutcomeCreated, "OPEN"},
+		{ActionOpen, OutcomeUnchanged, "OPEN"},
+		{ActionClose, OutcomeClosed, "CLOSED"},
+		{ActionMerge, OutcomeIgnored, "CLOSED"},
+		{ActionReopen, OutcomeReopened, "OPEN"},
+		{ActionReopen, OutcomeUnchanged, "OPEN"},
+		{ActionMerge, OutcomeMerged, "MERGED"},
+		{ActionMerge, OutcomeUnchanged, "MERGED"},
+		{ActionClose, OutcomeIgnored, "MERGED"},
+	}
+
+	for _, step := range steps {
+		res, err := s.Apply(ctx, event(step.action), policy)
+		require.NoError(t, err, step.action)
+		assert.Equal(t, step.outcome, res.Outcome, step.action)
+		assert.Equal(t, step.status, db.status["github:acme/api#7"], step.action)
+	}
+
+	require.Len(t, db.created, 1)
+	assert.Equal(t, "u1", db.created[0].AuthorID)
+}
+
+func TestSyncer_ReopenUnknownCreates(t *testing.T) {
+	db := newFakeStore()
+
+	res, err := NewSyncer(db).Apply(context.Background(), event(ActionReopen), models.ReviewerPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, OutcomeCreated, res.Outcome)
+	require.NotNil(t, res.PullRequest)
+}
+
+func TestSyncer_ResolvesAuthorByExternalID(t *testing.T) {
+	db := newFakeStore()
+	s := NewSyncer(db)
+	ctx := context.Background()
+
+	byAuthor := event(ActionOpen)
+	byAuthor.AuthorExternalID = "101"
+	res, err := s.Apply(ctx, byAuthor, models.ReviewerPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, OutcomeCreated, res.Outcome)
+
+	// черновик помечен готовым не автором: в событии только id автора
+	byMaintainer := event(ActionReopen)
+	byMaintainer.PullRequestID = "github:acme/api#8"
+	byMaintainer.AuthorLogin = ""
+	byMaintainer.AuthorExternalID = "101"
+	res, err = s.Apply(ctx, byMaintainer, models.ReviewerPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, OutcomeCreated, res.Outcome)
+
+	require.Len(t, db.created, 2)
+	assert.Equal(t, "u1", db.created[1].AuthorID)
+}
+
+func TestSyncer_Ignored(t *testing.T) {
+	db := newFakeStore()
+	s := NewSyncer(db)
+	ctx := context.Background()
+
+	unknown := event(ActionOpen)
+	unknown.AuthorLogin = "stranger"
+	res, err := s.Apply(ctx, unknown, models.ReviewerPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Outcome: OutcomeIgnored, Reason: "unknown_author"}, res)
+
+	res, err = s.Apply(ctx, event(ActionClose), models.ReviewerPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Outcome: OutcomeIgnored, Reason: "unknown_pull_request"}, res)
+
+	long := event(ActionOpen)
+	long.PullRequestID = "github:" + strings.Repeat("a", 200) + "#1"
+	res, err = s.Apply(ctx, long, models.ReviewerPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, "invalid_pull_request_id", res.Reason)
+
+	assert.Empty(t, db.created)
+}
@@ -0,0 +1,160 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/Parnishkaspb/avito/internal/queue"
+)
+
+const maxErrorBodyBytes = 4 << 10
+
+// ErrNotRetryable помечает ответы code host, которые повтор не исправит (нет прав, PR не найден, ...).
+var ErrNotRetryable = errors.New("code host rejected the request")
+
+// ReviewerClient запрашивает ревью у пользователей code host (add) и снимает запрос (remove).
+// Логины — как в identity_mappings. Повторный вызов с теми же аргументами должен быть безопасен.
+type ReviewerClient interface {
+	RequestReviewers(ctx context.Context, prID string, add, remove []string) error
+}
+
+// CheckResponse превращает не-2xx ответ code host в ошибку; 4xx, кроме 408 и 429, — ErrNotRetryable.
+func CheckResponse(provider string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	err := fmt.Errorf("%s: unexpected status %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return err
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return fmt.Errorf("%w: %w", ErrNotRetryable, err)
+	}
+	return err
+}
+
+type ReviewerSyncStore interface {
+	ClaimReviewerSyncs(ctx context.Context, limit int, lease time.Duration) ([]models.PendingReviewerSync, error)
+	RecordReviewerSync(ctx context.Context, prID string, revision int, attempt models.ReviewerSyncAttempt) error
+	ListIdentityLogins(ctx context.Context, provider string, userIDs []string) (map[string]string, error)
+}
+
+// ReviewerSyncer отправляет назначенных ревьюеров в code host для PR из очереди reviewer_sync.
+type ReviewerSyncer struct {
+	store   ReviewerSyncStore
+	clients map[string]ReviewerClient
+	poller  queue.Poller[models.PendingReviewerSync]
+	retry   queue.Retry
+	log     *slog.Logger
+}
+
+func NewReviewerSyncer(store ReviewerSyncStore, clients map[string]ReviewerClient, cfg config.ReviewerSyncConfig, log *slog.Logger) *ReviewerSyncer {
+	s := &ReviewerSyncer{
+		store:   store,
+		clients: clients,
+		retry:   queue.Retry{MaxAttempts: cfg.MaxAttempts, BackoffBase: cfg.BackoffBase, BackoffMax: cfg.BackoffMax},
+		log:     log.With(slog.String("component", "reviewer_sync")),
+	}
+	s.poller = queue.Poller[models.PendingReviewerSync]{
+		PollInterval: cfg.PollInterval,
+		BatchSize:    cfg.BatchSize,
+		Lease:        2 * cfg.Timeout,
+		Claim:        store.ClaimReviewerSyncs,
+		Handle:       s.syncOne,
+		Log:          s.log,
+	}
+	return s
+}
+
+func (s *ReviewerSyncer) Run(ctx context.Context) error {
+	return s.poller.Run(ctx)
+}
+
+// Sync синхронизирует одну пачку PR из очереди и возвращает её размер.
+func (s *ReviewerSyncer) Sync(ctx context.Context) int {
+	return s.poller.Poll(ctx)
+}
+
+func (s *ReviewerSyncer) syncOne(ctx context.Context, p models.PendingReviewerSync) {
+	log := s.log.With(
+		slog.String("pull_request_id", p.PullRequestID),
+		slog.String("provider", p.Provider),
+	)
+
+	attempt, retryable := s.push(ctx, p)
+	if ctx.Err() != nil {
+		// остановка сервиса — попытку не засчитываем, PR вернётся в очередь по истечении lease
+		return
+	}
+
+	if !attempt.Synced {
+		attempts := p.Attempts + 1
+		if delay, ok := s.retry.Next(attempts); retryable && ok {
+			attempt.RetryAfter = delay
+			log.WarnContext(ctx, "reviewer sync failed, will retry",
+				slog.Int("attempt", attempts), slog.Duration("retry_after", attempt.RetryAfter), slog.String("error", attempt.Error))
+		} else {
+			log.ErrorContext(ctx, "reviewer sync failed permanently",
+				slog.Int("attempt", attempts), slog.String("error", attempt.Error))
+		}
+	}
+
+	if err := s.store.RecordReviewerSync(ctx, p.PullRequestID, p.Revision, attempt); err != nil {
+		log.ErrorContext(ctx, "failed to record reviewer sync", slog.Any("error", err))
+	}
+}
+
+func (s *ReviewerSyncer) push(ctx context.Context, p models.PendingReviewerSync) (models.ReviewerSyncAttempt, bool) {
+	client, ok := s.clients[p.Provider]
+	if !ok {
+		return models.ReviewerSyncAttempt{Error: p.Provider + " api client is not configured"}, false
+	}
+
+	// ревьюер мог быть снят и назначен снова — тогда его не снимаем
+	removed := slices.DeleteFunc(slices.Clone(p.RemovedReviewers), func(id string) bool {
+		return slices.Contains(p.Reviewers, id)
+	})
+	slices.Sort(removed)
+	removed = slices.Compact(removed)
+
+	logins, err := s.store.ListIdentityLogins(ctx, p.Provider, append(slices.Clone(p.Reviewers), removed...))
+	if err != nil {
+		return models.ReviewerSyncAttempt{Error: err.Error()}, true
+	}
+
+	add, unmapped := toLogins(p.Reviewers, logins)
+	remove, _ := toLogins(removed, logins)
+
+	if err := client.RequestReviewers(ctx, p.PullRequestID, add, remove); err != nil {
+		return models.ReviewerSyncAttempt{Error: err.Error()}, !errors.Is(err, ErrNotRetryable)
+	}
+
+	attempt := models.ReviewerSyncAttempt{Synced: true}
+	if len(unmapped) > 0 {
+		attempt.Error = "no identity mapping for: " + strings.Join(unmapped, ", ")
+	}
+	return attempt, false
+}
+
+func toLogins(userIDs []string, logins map[string]string) (mapped, unmapped []string) {
+	for _, id := range userIDs {
+		if login, ok := logins[id]; ok {
+			mapped = append(mapped, login)
+		} else {
+			unmapped = append(unmapped, id)
+		}
+	}
+	return mapped, unmapped
+}
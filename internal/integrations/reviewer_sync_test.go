@@ -0,0 +1,133 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSyncStore struct {
+	mu       sync.Mutex
+	pending  []models.PendingReviewerSync
+	logins   map[string]string
+	recorded map[string]models.ReviewerSyncAttempt
+}
+
+func (f *fakeSyncStore) ClaimReviewerSyncs(_ context.Context, limit int, _ time.Duration) ([]models.PendingReviewerSync, error) {
+	n := min(limit, len(f.pending))
+	claimed := f.pending[:n]
+	f.pending = f.pending[n:]
+	return claimed, nil
+}
+
+func (f *fakeSyncStore) RecordReviewerSync(_ context.Context, prID string, _ int, attempt models.ReviewerSyncAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recorded[prID] = attempt
+	return nil
+}
+
+func (f *fakeSyncStore) ListIdentityLogins(_ context.Context, _ string, userIDs []string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, id := range userIDs {
+		if login, ok := f.logins[id]; ok {
+			out[id] = login
+		}
+	}
+	return out, nil
+}
+
+type reviewerCall struct {
+	add, remove []string
+}
+
+type fakeReviewerClient struct {
+	mu    sync.Mutex
+	calls map[string]reviewerCall
+	err   error
+}
+
+func (f *fakeReviewerClient) RequestReviewers(_ context.Context, prID string, add, remove []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[prID] = reviewerCall{add: add, remove: remove}
+	return f.err
+}
+
+func newSyncer(store *fakeSyncStore, client *fakeReviewerClient) *ReviewerSyncer {
+	cfg := config.ReviewerSyncConfig{BatchSize: 10, Timeout: time.Second, MaxAttempts: 3, BackoffBase: time.Second, BackoffMax: time.Minute}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewReviewerSyncer(store, map[string]ReviewerClient{models.ProviderGitHub: client}, cfg, log)
+}
+
+func TestReviewerSyncer_PushesMappedReviewers(t *testing.T) {
+	store := &fakeSyncStore{
+		pending: []models.PendingReviewerSync{{
+			PullRequestID:    "github:acme/api#7",
+			Provider:         models.ProviderGitHub,
+			Reviewers:        []string{"u2", "u3", "u4"},
+			RemovedReviewers: []string{"u5", "u3", "u5"},
+		}},
+		logins:   map[string]string{"u2": "hubot", "u3": "monalisa", "u5": "defunkt"},
+		recorded: map[string]models.ReviewerSyncAttempt{},
+	}
+	client := &fakeReviewerClient{calls: map[string]reviewerCall{}}
+
+	assert.Equal(t, 1, newSyncer(store, client).Sync(context.Background()))
+
+	call := client.calls["github:acme/api#7"]
+	assert.Equal(t, []string{"hubot", "monalisa"}, call.add)
+	assert.Equal(t, []string{"defunkt"}, call.remove)
+
+	attempt := store.recorded["github:acme/api#7"]
+	assert.True(t, attempt.Synced)
+	assert.Equal(t, "no identity mapping for: u4", attempt.Error)
+}
+
+func TestReviewerSyncer_RetriesOnlyTransientErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		attempts int
+		err      error
+		retry    bool
+	}{
+		{"transient", models.ProviderGitHub, 0, errors.New("connection reset"), true},
+		{"rejected", models.ProviderGitHub, 0, ErrNotRetryable, false},
+		{"out of attempts", models.ProviderGitHub, 2, errors.New("connection reset"), false},
+		{"no client", models.ProviderGitLab, 0, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeSyncStore{
+				pending: []models.PendingReviewerSync{{
+					PullRequestID: "pr",
+					Provider:      tt.provider,
+					Attempts:      tt.attempts,
+					Reviewers:     []string{"u2"},
+				}},
+				logins:   map[string]string{"u2": "hubot"},
+				recorded: map[string]models.ReviewerSyncAttempt{},
+			}
+			client := &fakeReviewerClient{calls: map[string]reviewerCall{}, err: tt.err}
+
+			newSyncer(store, client).Sync(context.Background())
+
+			attempt, ok := store.recorded["pr"]
+			require.True(t, ok)
+			assert.False(t, attempt.Synced)
+			assert.NotEmpty(t, attempt.Error)
+			assert.Equal(t, tt.retry, attempt.RetryAfter > 0)
+		})
+	}
+}
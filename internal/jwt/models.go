@@ -6,9 +6,10 @@ import (
 )
 
 type CustomClaims struct {
-	UserID string `json:"user_id"`
-	Name   string `json:"name"`
-	Role   bool   `json:"role"`
+	UserID   string `json:"user_id"`
+	Name     string `json:"name"`
+	Role     bool   `json:"role"`
+	OrgAdmin bool   `json:"org_admin,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -2,11 +2,13 @@ package jwt
 
 import (
 	"github.com/golang-jwt/jwt/v5"
+	"sync"
 	"time"
 )
 
 type Service struct {
 	config Config
+	mu     sync.RWMutex
 }
 
 func New(config Config) *Service {
@@ -25,13 +27,38 @@ func New(config Config) *Service {
 	}
 }
 
-func (s *Service) GenerateAccessToken(userID, name string, role bool) (string, error) {
+func (s *Service) SetTokenTTL(accessTokenTTL, refreshTokenTTL time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if accessTokenTTL > 0 {
+		s.config.AccessTokenTTL = accessTokenTTL
+	}
+	if refreshTokenTTL > 0 {
+		s.config.RefreshTokenTTL = refreshTokenTTL
+	}
+}
+
+func (s *Service) accessTokenTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.AccessTokenTTL
+}
+
+func (s *Service) refreshTokenTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.RefreshTokenTTL
+}
+
+func (s *Service) GenerateAccessToken(userID, name string, role, orgAdmin bool) (string, error) {
 	claims := CustomClaims{
-		UserID: userID,
-		Name:   name,
-		Role:   role,
+		UserID:   userID,
+		Name:     name,
+		Role:     role,
+		OrgAdmin: orgAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.config.AccessTokenTTL)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTokenTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    s.config.Issuer,
@@ -48,13 +75,14 @@ func (s *Service) GenerateAccessToken(userID, name string, role bool) (string, e
 	return tokenString, nil
 }
 
-func (s *Service) GenerateRefreshToken(userID, name string, role bool) (string, error) {
+func (s *Service) GenerateRefreshToken(userID, name string, role, orgAdmin bool) (string, error) {
 	claims := CustomClaims{
-		UserID: userID,
-		Name:   name,
-		Role:   role,
+		UserID:   userID,
+		Name:     name,
+		Role:     role,
+		OrgAdmin: orgAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.config.RefreshTokenTTL)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.refreshTokenTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    s.config.Issuer,
@@ -71,13 +99,13 @@ func (s *Service) GenerateRefreshToken(userID, name string, role bool) (string,
 	return tokenString, nil
 }
 
-func (s *Service) GenerateTokenPair(userID, name string, role bool) (*TokenPair, error) {
-	accessToken, err := s.GenerateAccessToken(userID, name, role)
+func (s *Service) GenerateTokenPair(userID, name string, role, orgAdmin bool) (*TokenPair, error) {
+	accessToken, err := s.GenerateAccessToken(userID, name, role, orgAdmin)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := s.GenerateRefreshToken(userID, name, role)
+	refreshToken, err := s.GenerateRefreshToken(userID, name, role, orgAdmin)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +114,7 @@ func (s *Service) GenerateTokenPair(userID, name string, role bool) (*TokenPair,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    int64(s.config.AccessTokenTTL.Seconds()),
+		ExpiresIn:    int64(s.accessTokenTTL().Seconds()),
 	}, nil
 }
 
@@ -118,5 +146,5 @@ func (s *Service) RefreshTokens(refreshToken string) (*TokenPair, error) {
 		return nil, err
 	}
 
-	return s.GenerateTokenPair(claims.UserID, claims.Name, claims.Role)
+	return s.GenerateTokenPair(claims.UserID, claims.Name, claims.Role, claims.OrgAdmin)
 }
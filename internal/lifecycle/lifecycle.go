@@ -0,0 +1,187 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultStartTimeout = 30 * time.Second
+	defaultStopTimeout  = 15 * time.Second
+)
+
+var (
+	ErrStartTimeout   = errors.New("component did not become ready in time")
+	ErrStopTimeout    = errors.New("component did not stop in time")
+	ErrUnexpectedExit = errors.New("component exited unexpectedly")
+	ErrNoComponents   = errors.New("no components registered")
+)
+
+type Component struct {
+	Name        string
+	Run         func(ctx context.Context) error
+	Ready       func() <-chan struct{}
+	StopTimeout time.Duration
+}
+
+type Manager struct {
+	log          *slog.Logger
+	components   []Component
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+}
+
+type running struct {
+	component Component
+	cancel    context.CancelFunc
+	done      chan struct{}
+	err       error
+	reported  bool
+}
+
+func New(log *slog.Logger, startTimeout, stopTimeout time.Duration) *Manager {
+	if log == nil {
+		log = slog.Default()
+	}
+	if startTimeout <= 0 {
+		startTimeout = defaultStartTimeout
+	}
+	if stopTimeout <= 0 {
+		stopTimeout = defaultStopTimeout
+	}
+
+	return &Manager{
+		log:          log.With(slog.String("component", "lifecycle")),
+		startTimeout: startTimeout,
+		stopTimeout:  stopTimeout,
+	}
+}
+
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+func (m *Manager) Run(ctx context.Context) error {
+	if len(m.components) == 0 {
+		return ErrNoComponents
+	}
+
+	exited := make(chan *running, len(m.components))
+	started := make([]*running, 0, len(m.components))
+
+	err := func() error {
+		for _, c := range m.components {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			r := m.start(ctx, c, exited)
+			started = append(started, r)
+
+			if err := m.waitReady(ctx, r, exited); err != nil {
+				return err
+			}
+			m.log.Info("component started", slog.String("name", c.Name))
+		}
+		return nil
+	}()
+
+	switch {
+	case err == nil:
+		select {
+		case <-ctx.Done():
+			m.log.Info("shutdown requested")
+		case r := <-exited:
+			err = r.exitError()
+			m.log.Error("component failed", slog.String("name", r.component.Name), slog.Any("error", err))
+		}
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		// остановка во время запуска — штатное завершение, оставшиеся компоненты не запускаются
+		m.log.Info("shutdown requested during startup")
+		err = nil
+	}
+
+	return errors.Join(err, m.stop(started))
+}
+
+func (m *Manager) start(ctx context.Context, c Component, exited chan<- *running) *running {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r := &running{component: c, cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(r.done)
+		r.err = c.Run(runCtx)
+		if runCtx.Err() == nil {
+			exited <- r
+		}
+	}()
+
+	return r
+}
+
+func (m *Manager) waitReady(ctx context.Context, r *running, exited <-chan *running) error {
+	if r.component.Ready == nil {
+		return nil
+	}
+
+	timer := time.NewTimer(m.startTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-r.component.Ready():
+		return nil
+	case failed := <-exited:
+		return failed.exitError()
+	case <-timer.C:
+		return fmt.Errorf("%s: %w", r.component.Name, ErrStartTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) stop(started []*running) error {
+	var errs []error
+
+	for i := len(started) - 1; i >= 0; i-- {
+		r := started[i]
+		timeout := r.component.StopTimeout
+		if timeout <= 0 {
+			timeout = m.stopTimeout
+		}
+
+		m.log.Info("stopping component", slog.String("name", r.component.Name))
+		r.cancel()
+
+		timer := time.NewTimer(timeout)
+		select {
+		case <-r.done:
+			if r.err != nil && !r.reported && !errors.Is(r.err, context.Canceled) {
+				errs = append(errs, fmt.Errorf("%s: %w", r.component.Name, r.err))
+			}
+		case <-timer.C:
+			m.log.Error("component stop timed out", slog.String("name", r.component.Name), slog.Duration("timeout", timeout))
+			errs = append(errs, fmt.Errorf("%s: %w", r.component.Name, ErrStopTimeout))
+		}
+		timer.Stop()
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *running) exitError() error {
+	r.reported = true
+	if r.err != nil {
+		return fmt.Errorf("%s: %w", r.component.Name, r.err)
+	}
+	return fmt.Errorf("%s: %w", r.component.Name, ErrUnexpectedExit)
+}
+
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return 1
+}
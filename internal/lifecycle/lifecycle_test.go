@@ -0,0 +1,228 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func newTestManager() *Manager {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), time.Second, time.Second)
+}
+
+func blockingComponent(name string, rec *recorder) Component {
+	ready := make(chan struct{})
+	return Component{
+		Name: name,
+		Run: func(ctx context.Context) error {
+			rec.add("start " + name)
+			close(ready)
+			<-ctx.Done()
+			rec.add("stop " + name)
+			return nil
+		},
+		Ready: func() <-chan struct{} { return ready },
+	}
+}
+
+func TestManager_StartsInOrderAndStopsInReverse(t *testing.T) {
+	rec := &recorder{}
+	m := newTestManager()
+	m.Add(blockingComponent("database", rec))
+	m.Add(blockingComponent("server", rec))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return len(rec.list()) == 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"start database", "start server", "stop server", "stop database"}, rec.list())
+}
+
+func TestManager_WaitsForReadinessBeforeNextComponent(t *testing.T) {
+	rec := &recorder{}
+	release := make(chan struct{})
+	ready := make(chan struct{})
+
+	m := newTestManager()
+	m.Add(Component{
+		Name: "database",
+		Run: func(ctx context.Context) error {
+			<-release
+			rec.add("database ready")
+			close(ready)
+			<-ctx.Done()
+			return nil
+		},
+		Ready: func() <-chan struct{} { return ready },
+	})
+	m.Add(blockingComponent("server", rec))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, rec.list())
+
+	close(release)
+	require.Eventually(t, func() bool { return len(rec.list()) == 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"database ready", "start server", "stop server"}, rec.list())
+}
+
+func TestManager_ShutdownBeforeReadySkipsRemaining(t *testing.T) {
+	rec := &recorder{}
+
+	m := newTestManager()
+	m.Add(Component{
+		Name: "database",
+		Run: func(ctx context.Context) error {
+			rec.add("start database")
+			<-ctx.Done()
+			rec.add("stop database")
+			return nil
+		},
+		Ready: func() <-chan struct{} { return make(chan struct{}) },
+	})
+	m.Add(blockingComponent("server", rec))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return len(rec.list()) == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"start database", "stop database"}, rec.list())
+}
+
+func TestManager_ComponentFailureStopsOthers(t *testing.T) {
+	rec := &recorder{}
+	boom := errors.New("boom")
+	fail := make(chan struct{})
+
+	m := newTestManager()
+	m.Add(blockingComponent("database", rec))
+	m.Add(Component{
+		Name: "server",
+		Run: func(ctx context.Context) error {
+			<-fail
+			return boom
+		},
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- m.Run(context.Background()) }()
+
+	close(fail)
+	err := <-done
+
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, ExitCode(err))
+	assert.Equal(t, []string{"start database", "stop database"}, rec.list())
+}
+
+func TestManager_StartFailure(t *testing.T) {
+	rec := &recorder{}
+	boom := errors.New("connect refused")
+
+	m := newTestManager()
+	m.Add(Component{
+		Name:  "database",
+		Run:   func(ctx context.Context) error { return boom },
+		Ready: func() <-chan struct{} { return make(chan struct{}) },
+	})
+	m.Add(blockingComponent("server", rec))
+
+	err := m.Run(context.Background())
+	assert.ErrorIs(t, err, boom)
+	assert.Empty(t, rec.list())
+}
+
+func TestManager_StartTimeout(t *testing.T) {
+	m := New(slog.New(slog.NewTextHandler(io.Discard, nil)), 20*time.Millisecond, time.Second)
+	m.Add(Component{
+		Name: "database",
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		},
+		Ready: func() <-chan struct{} { return make(chan struct{}) },
+	})
+
+	err := m.Run(context.Background())
+	assert.ErrorIs(t, err, ErrStartTimeout)
+}
+
+func TestManager_StopTimeout(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+	started := make(chan struct{})
+
+	m := newTestManager()
+	m.Add(Component{
+		Name: "server",
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-stuck
+			return nil
+		},
+		StopTimeout: 20 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	<-started
+	cancel()
+
+	assert.ErrorIs(t, <-done, ErrStopTimeout)
+}
+
+func TestManager_UnexpectedExit(t *testing.T) {
+	m := newTestManager()
+	m.Add(Component{
+		Name: "worker",
+		Run:  func(ctx context.Context) error { return nil },
+	})
+
+	err := m.Run(context.Background())
+	assert.ErrorIs(t, err, ErrUnexpectedExit)
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, ExitCode(nil))
+	assert.Equal(t, 1, ExitCode(errors.New("fail")))
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type attrsKey struct{}
+
+func New(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(level)
+	opts := &slog.HandlerOptions{Level: levelVar}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler, level: levelVar}), nil
+}
+
+func SetLevel(log *slog.Logger, value string) error {
+	set, err := PrepareLevel(log, value)
+	if err != nil {
+		return err
+	}
+
+	set()
+	return nil
+}
+
+// PrepareLevel проверяет уровень и возвращает функцию, которая его устанавливает.
+func PrepareLevel(log *slog.Logger, value string) (func(), error) {
+	h, ok := log.Handler().(*contextHandler)
+	if !ok {
+		return nil, fmt.Errorf("logger was not created by logger.New")
+	}
+
+	level, err := ParseLevel(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { h.level.Set(level) }, nil
+}
+
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", value)
+	}
+
+	return level, nil
+}
+
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+type contextHandler struct {
+	slog.Handler
+	level *slog.LevelVar
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
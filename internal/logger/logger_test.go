@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_ContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(config.LogConfig{Level: "debug", Format: FormatJSON}, &buf)
+	require.NoError(t, err)
+
+	ctx := WithAttrs(context.Background(), slog.String("request_id", "req-1"))
+	ctx = WithAttrs(ctx, slog.String("user_id", "u1"))
+	log.With(slog.String("component", "database")).DebugContext(ctx, "query finished")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "query finished", entry["msg"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "u1", entry["user_id"])
+	assert.Equal(t, "database", entry["component"])
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(config.LogConfig{Level: "warn", Format: FormatText}, &buf)
+	require.NoError(t, err)
+
+	log.Info("hidden")
+	assert.Empty(t, buf.String())
+
+	log.Warn("visible")
+	assert.Contains(t, buf.String(), "visible")
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(config.LogConfig{Level: "loud"}, &bytes.Buffer{})
+	assert.Error(t, err)
+
+	_, err = New(config.LogConfig{Format: "xml"}, &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(config.LogConfig{Level: "info", Format: FormatText}, &buf)
+	require.NoError(t, err)
+
+	derived := log.With(slog.String("component", "server"))
+	derived.Debug("hidden")
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, SetLevel(log, "debug"))
+	derived.Debug("visible")
+	assert.Contains(t, buf.String(), "visible")
+
+	assert.Error(t, SetLevel(log, "loud"))
+	assert.Error(t, SetLevel(slog.New(slog.NewTextHandler(&buf, nil)), "debug"))
+}
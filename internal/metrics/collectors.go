@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const reviewLoadTimeout = 5 * time.Second
+
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+}
+
+func newPoolCollector(stat func() *pgxpool.Stat) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		stat:              stat,
+		acquiredConns:     desc("acquired_conns", "Number of currently acquired connections."),
+		idleConns:         desc("idle_conns", "Number of currently idle connections."),
+		constructingConns: desc("constructing_conns", "Number of connections being constructed."),
+		totalConns:        desc("total_conns", "Total number of connections in the pool."),
+		maxConns:          desc("max_conns", "Maximum size of the pool."),
+		acquireCount:      desc("acquire_total", "Cumulative count of successful acquires."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Total time spent waiting for connections."),
+		emptyAcquireCount: desc("empty_acquire_total", "Cumulative count of acquires that waited for a connection."),
+		canceledAcquires:  desc("canceled_acquire_total", "Cumulative count of acquires canceled by context."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.canceledAcquires
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.stat()
+	if stat == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
+
+type reviewLoadCollector struct {
+	load func(ctx context.Context) (map[string]int, error)
+
+	openReviews *prometheus.Desc
+	scrapeError *prometheus.Desc
+}
+
+func newReviewLoadCollector(load func(ctx context.Context) (map[string]int, error)) *reviewLoadCollector {
+	return &reviewLoadCollector{
+		load: load,
+		openReviews: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "reviewer", "open_reviews"),
+			"Number of OPEN pull requests currently assigned to the reviewer.",
+			[]string{"reviewer_id"}, nil,
+		),
+		scrapeError: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "reviewer", "load_scrape_error"),
+			"1 if the last reviewer load query failed.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *reviewLoadCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openReviews
+	ch <- c.scrapeError
+}
+
+func (c *reviewLoadCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), reviewLoadTimeout)
+	defer cancel()
+
+	load, err := c.load(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.scrapeError, prometheus.GaugeValue, 1)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.scrapeError, prometheus.GaugeValue, 0)
+	for reviewerID, count := range load {
+		ch <- prometheus.MustNewConstMetric(c.openReviews, prometheus.GaugeValue, float64(count), reviewerID)
+	}
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "avito"
+
+type Metrics struct {
+	registry        *prometheus.Registry
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	prsCreated      prometheus.Counter
+	prsMerged       prometheus.Counter
+	reassignments   prometheus.Counter
+	noCandidate     prometheus.Counter
+	reviewersPicked prometheus.Histogram
+	rateLimited     *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		prsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pull_requests_created_total",
+			Help:      "Number of created pull requests.",
+		}),
+		prsMerged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pull_requests_merged_total",
+			Help:      "Number of pull requests transitioned to MERGED.",
+		}),
+		reassignments: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reviewer_reassignments_total",
+			Help:      "Number of successful reviewer reassignments.",
+		}),
+		noCandidate: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reviewer_no_candidate_total",
+			Help:      "Number of reassignments rejected with NO_CANDIDATE.",
+		}),
+		reviewersPicked: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "pull_request_reviewers_assigned",
+			Help:      "Number of reviewers assigned on pull request creation.",
+			Buckets:   []float64{0, 1, 2, 3, 5},
+		}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "rate_limited_total",
+			Help:      "Number of requests rejected with RATE_LIMITED by route.",
+		}, []string{"route"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.prsCreated,
+		m.prsMerged,
+		m.reassignments,
+		m.noCandidate,
+		m.reviewersPicked,
+		m.rateLimited,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) RegisterPool(stat func() *pgxpool.Stat) {
+	m.registry.MustRegister(newPoolCollector(stat))
+}
+
+func (m *Metrics) RegisterReviewLoad(load func(ctx context.Context) (map[string]int, error)) {
+	m.registry.MustRegister(newReviewLoadCollector(load))
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+func (m *Metrics) PullRequestCreated(reviewers int) {
+	if m == nil {
+		return
+	}
+
+	m.prsCreated.Inc()
+	m.reviewersPicked.Observe(float64(reviewers))
+}
+
+func (m *Metrics) PullRequestMerged() {
+	if m == nil {
+		return
+	}
+
+	m.prsMerged.Inc()
+}
+
+func (m *Metrics) ReviewerReassigned() {
+	if m == nil {
+		return
+	}
+
+	m.reassignments.Inc()
+}
+
+func (m *Metrics) NoCandidate() {
+	if m == nil {
+		return
+	}
+
+	m.noCandidate.Inc()
+}
+
+func (m *Metrics) RateLimited(route string) {
+	if m == nil {
+		return
+	}
+
+	m.rateLimited.WithLabelValues(route).Inc()
+}
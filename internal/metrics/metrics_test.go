@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics_Exposition(t *testing.T) {
+	m := New()
+	m.RegisterPool(func() *pgxpool.Stat { return nil })
+	m.RegisterReviewLoad(func(context.Context) (map[string]int, error) {
+		return map[string]int{"u1": 3, "u2": 1}, nil
+	})
+
+	m.ObserveHTTPRequest(http.MethodPost, "/pullRequest/create", http.StatusOK, 50*time.Millisecond)
+	m.PullRequestCreated(2)
+	m.PullRequestMerged()
+	m.ReviewerReassigned()
+	m.NoCandidate()
+
+	body := scrape(t, m)
+
+	assert.Contains(t, body, `avito_http_requests_total{method="POST",route="/pullRequest/create",status="200"} 1`)
+	assert.Contains(t, body, `avito_http_request_duration_seconds_bucket{method="POST",route="/pullRequest/create",status="200",le="0.05"} 1`)
+	assert.Contains(t, body, "avito_pull_requests_created_total 1")
+	assert.Contains(t, body, "avito_pull_requests_merged_total 1")
+	assert.Contains(t, body, "avito_reviewer_reassignments_total 1")
+	assert.Contains(t, body, "avito_reviewer_no_candidate_total 1")
+	assert.Contains(t, body, `avito_reviewer_open_reviews{reviewer_id="u1"} 3`)
+	assert.Contains(t, body, "avito_reviewer_load_scrape_error 0")
+	assert.NotContains(t, body, "avito_db_pool_total_conns")
+}
+
+func TestMetrics_ReviewLoadError(t *testing.T) {
+	m := New()
+	m.RegisterReviewLoad(func(context.Context) (map[string]int, error) {
+		return nil, errors.New("db is down")
+	})
+
+	assert.Contains(t, scrape(t, m), "avito_reviewer_load_scrape_error 1")
+}
+
+func TestMetrics_NilIsNoop(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.ObserveHTTPRequest(http.MethodGet, "/", http.StatusOK, time.Second)
+		m.PullRequestCreated(1)
+		m.PullRequestMerged()
+		m.ReviewerReassigned()
+		m.NoCandidate()
+	})
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+)
+
+const (
+	ScopePullRequestCreate       = "pullRequest:create"
+	ScopePullRequestMerge        = "pullRequest:merge"
+	ScopePullRequestReassign     = "pullRequest:reassign"
+	ScopePullRequestReviewerSync = "pullRequest:reviewerSync"
+	ScopeTeamCodeowners          = "team:codeowners"
+	ScopeTeamGet                 = "team:get"
+	ScopeUsersGetReview          = "users:getReview"
+	ScopeUsersSetIsActive        = "users:setIsActive"
+)
+
+var APIKeyScopes = []string{
+	ScopePullRequestCreate,
+	ScopePullRequestMerge,
+	ScopePullRequestReassign,
+	ScopePullRequestReviewerSync,
+	ScopeTeamCodeowners,
+	ScopeTeamGet,
+	ScopeUsersGetReview,
+	ScopeUsersSetIsActive,
+}
+
+type APIKey struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	ServiceAccount string     `json:"service_account"`
+	Prefix         string     `json:"prefix"`
+	Scopes         []string   `json:"scopes"`
+	CreatedBy      string     `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	// ServiceAccountAdmin — роль сервисного аккаунта; заполняется при создании и проверке ключа
+	ServiceAccountAdmin *bool  `json:"service_account_admin,omitempty"`
+	Hash                string `json:"-"`
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+type APIKeyCreateRequest struct {
+	Name           string     `json:"name"`
+	ServiceAccount string     `json:"service_account"`
+	Scopes         []string   `json:"scopes"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	// ServiceAccountAdmin задаёт роль сервисного аккаунта; без поля роль не меняется
+	// (у нового аккаунта — не администратор)
+	ServiceAccountAdmin *bool `json:"service_account_admin"`
+}
+
+func (r APIKeyCreateRequest) Validate() error {
+	if err := ValidateName("name", r.Name); err != nil {
+		return err
+	}
+
+	if err := ValidateID("service_account", r.ServiceAccount); err != nil {
+		return err
+	}
+
+	if len(r.Scopes) == 0 {
+		return apierror.Validation("validation.empty_list", map[string]string{"field": "scopes"})
+	}
+
+	seen := make(map[string]struct{}, len(r.Scopes))
+	for _, scope := range r.Scopes {
+		if !slices.Contains(APIKeyScopes, scope) {
+			return apierror.Validation("validation.unknown_scope", map[string]string{"field": "scopes", "value": scope})
+		}
+		if _, ok := seen[scope]; ok {
+			return apierror.Validation("validation.duplicate_member", map[string]string{"field": "scopes", "value": scope})
+		}
+		seen[scope] = struct{}{}
+	}
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return apierror.Validation("validation.in_past", map[string]string{"field": "expires_at"})
+	}
+
+	return nil
+}
+
+type APIKeyCreateResponse struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
+}
+
+type APIKeyRevokeRequest struct {
+	ID string `json:"id"`
+}
+
+func (r APIKeyRevokeRequest) Validate() error {
+	return ValidateID("id", r.ID)
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	APIKeyID   string          `json:"api_key_id,omitempty"`
+	Action     string          `json:"action"`
+	Target     string          `json:"target"`
+	RequestID  string          `json:"request_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+}
+
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	From   *time.Time
+	To     *time.Time
+	Cursor int64
+	Limit  int
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor *int64       `json:"next_cursor"`
+}
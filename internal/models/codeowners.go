@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/codeowners"
+)
+
+const (
+	maxCodeownersLength = 512 << 10
+	maxChangedFiles     = 3000
+	maxPathLength       = 1024
+)
+
+type Codeowners struct {
+	TeamName   string    `json:"team_name"`
+	Repository string    `json:"repository"`
+	Content    string    `json:"content"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type CodeownersRequest struct {
+	TeamName   string `json:"team_name"`
+	Repository string `json:"repository"`
+	Content    string `json:"content"`
+}
+
+func (r CodeownersRequest) Validate() error {
+	if err := validateCodeownersKey(r.TeamName, r.Repository); err != nil {
+		return err
+	}
+
+	if r.Content == "" {
+		return apierror.Validation("validation.required", map[string]string{"field": "content"})
+	}
+
+	if len(r.Content) > maxCodeownersLength {
+		return apierror.Validation("validation.too_long", map[string]string{"field": "content", "max": strconv.Itoa(maxCodeownersLength)})
+	}
+
+	if _, err := codeowners.Parse(r.Content); err != nil {
+		var parseErr *codeowners.ParseError
+		if errors.As(err, &parseErr) {
+			return apierror.Validation("validation.invalid_codeowners", map[string]string{"field": "content", "line": strconv.Itoa(parseErr.Line)})
+		}
+		return apierror.Validation("validation.invalid_format", map[string]string{"field": "content"})
+	}
+
+	return nil
+}
+
+type CodeownersDeleteRequest struct {
+	TeamName   string `json:"team_name"`
+	Repository string `json:"repository"`
+}
+
+func (r CodeownersDeleteRequest) Validate() error {
+	return validateCodeownersKey(r.TeamName, r.Repository)
+}
+
+func validateCodeownersKey(teamName, repository string) error {
+	if err := ValidateName("team_name", teamName); err != nil {
+		return err
+	}
+
+	return ValidateID("repository", repository)
+}
+
+func validateChangedFiles(paths []string) error {
+	if len(paths) > maxChangedFiles {
+		return apierror.Validation("validation.too_many", map[string]string{"field": "changed_files", "max": strconv.Itoa(maxChangedFiles)})
+	}
+
+	for _, path := range paths {
+		if path == "" {
+			return apierror.Validation("validation.required", map[string]string{"field": "changed_files"})
+		}
+		if len(path) > maxPathLength {
+			return apierror.Validation("validation.too_long", map[string]string{"field": "changed_files", "max": strconv.Itoa(maxPathLength)})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type IdempotencyRecord struct {
+	Key          string
+	Scope        string
+	Fingerprint  string
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+var IntegrationProviders = []string{
+	ProviderGitHub,
+	ProviderGitLab,
+}
+
+var pullRequestNumberSeparators = map[string]string{
+	ProviderGitHub: "#",
+	ProviderGitLab: "!",
+}
+
+type IdentityMapping struct {
+	Provider   string    `json:"provider"`
+	Login      string    `json:"login"`
+	ExternalID string    `json:"external_id,omitempty"`
+	UserID     string    `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type IdentityMappingRequest struct {
+	Provider   string `json:"provider"`
+	Login      string `json:"login"`
+	ExternalID string `json:"external_id"`
+	UserID     string `json:"user_id"`
+}
+
+func (r IdentityMappingRequest) Validate() error {
+	if err := validateProvider(r.Provider); err != nil {
+		return err
+	}
+
+	if err := ValidateName("login", r.Login); err != nil {
+		return err
+	}
+
+	if r.ExternalID != "" {
+		if err := ValidateID("external_id", r.ExternalID); err != nil {
+			return err
+		}
+	}
+
+	return ValidateID("user_id", r.UserID)
+}
+
+type IdentityMappingDeleteRequest struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+}
+
+func (r IdentityMappingDeleteRequest) Validate() error {
+	if err := validateProvider(r.Provider); err != nil {
+		return err
+	}
+
+	return ValidateName("login", r.Login)
+}
+
+// PullRequestProvider возвращает provider из id вида "github:owner/repo#42" или "" для PR,
+// созданных не через интеграцию.
+func PullRequestProvider(prID string) string {
+	provider, _, ok := strings.Cut(prID, ":")
+	if !ok || !slices.Contains(IntegrationProviders, provider) {
+		return ""
+	}
+	return provider
+}
+
+// PullRequestRepository возвращает репозиторий PR из интеграции: "github:owner/repo" для
+// "github:owner/repo#42", "gitlab:15" для "gitlab:15!7"; иначе "".
+func PullRequestRepository(prID string) string {
+	provider := PullRequestProvider(prID)
+	sep, ok := pullRequestNumberSeparators[provider]
+	if !ok {
+		return ""
+	}
+
+	i := strings.LastIndex(prID, sep)
+	if i <= len(provider)+1 {
+		return ""
+	}
+	return prID[:i]
+}
+
+func validateProvider(provider string) error {
+	if provider == "" {
+		return apierror.Validation("validation.required", map[string]string{"field": "provider"})
+	}
+
+	if !slices.Contains(IntegrationProviders, provider) {
+		return apierror.Validation("validation.invalid_format", map[string]string{"field": "provider"})
+	}
+
+	return nil
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"strings"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+)
+
 type RequestMembers struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
@@ -8,22 +14,129 @@ type RequestMembers struct {
 
 type UserActive struct {
 	UserID   string `json:"user_id"`
-	IsActive bool   `json:"is_active"`
+	IsActive *bool  `json:"is_active"`
+}
+
+func (r UserActive) Validate() error {
+	if err := ValidateID("user_id", r.UserID); err != nil {
+		return err
+	}
+
+	if r.IsActive == nil {
+		return apierror.Validation("validation.required", map[string]string{"field": "is_active"})
+	}
+
+	return nil
+}
+
+// UserIdentityRequest привязывает пользователя к SSO: nil оставляет поле как есть, пустая строка очищает его.
+type UserIdentityRequest struct {
+	UserID      string  `json:"user_id"`
+	Email       *string `json:"email"`
+	OIDCSubject *string `json:"oidc_subject"`
+}
+
+func (r UserIdentityRequest) Validate() error {
+	if err := ValidateID("user_id", r.UserID); err != nil {
+		return err
+	}
+
+	if r.Email == nil && r.OIDCSubject == nil {
+		return apierror.Validation("validation.required", map[string]string{"field": "email"})
+	}
+
+	if r.Email != nil && *r.Email != "" {
+		if err := ValidateName("email", *r.Email); err != nil {
+			return err
+		}
+		if !strings.Contains(*r.Email, "@") {
+			return apierror.Validation("validation.invalid_format", map[string]string{"field": "email"})
+		}
+	}
+
+	if r.OIDCSubject != nil && *r.OIDCSubject != "" {
+		return ValidateName("oidc_subject", *r.OIDCSubject)
+	}
+
+	return nil
 }
 
 type PullRequestCreateRequest struct {
 	PullRequestId   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+	// Repository и ChangedFiles необязательны: по ним ищутся владельцы кода в CODEOWNERS команды
+	Repository   string   `json:"repository,omitempty"`
+	ChangedFiles []string `json:"changed_files,omitempty"`
+}
+
+// RepositoryName возвращает репозиторий из запроса, а для PR из интеграции — из его id.
+func (r PullRequestCreateRequest) RepositoryName() string {
+	if r.Repository != "" {
+		return r.Repository
+	}
+	return PullRequestRepository(r.PullRequestId)
+}
+
+func (r PullRequestCreateRequest) Validate() error {
+	if err := ValidateID("pull_request_id", r.PullRequestId); err != nil {
+		return err
+	}
+
+	if err := ValidateName("pull_request_name", r.PullRequestName); err != nil {
+		return err
+	}
+
+	if err := ValidateID("author_id", r.AuthorID); err != nil {
+		return err
+	}
+
+	if r.Repository != "" {
+		if err := ValidateID("repository", r.Repository); err != nil {
+			return err
+		}
+	}
+
+	return validateChangedFiles(r.ChangedFiles)
 }
 
 type MergePRRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 }
 
+func (r MergePRRequest) Validate() error {
+	return ValidateID("pull_request_id", r.PullRequestID)
+}
+
 type MergePRRequestReasing struct {
 	PullRequestID string `json:"pull_request_id"`
 	OldReviewerID string `json:"old_reviewer_id"`
+	OldUserID     string `json:"old_user_id"`
+}
+
+func (r *MergePRRequestReasing) Validate() error {
+	if err := ValidateID("pull_request_id", r.PullRequestID); err != nil {
+		return err
+	}
+
+	if r.OldReviewerID == "" {
+		r.OldReviewerID = r.OldUserID
+	}
+
+	return ValidateID("old_reviewer_id", r.OldReviewerID)
+}
+
+type LoginRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (r LoginRequest) Validate() error {
+	if err := ValidateID("id", r.ID); err != nil {
+		return err
+	}
+
+	return ValidateName("name", r.Name)
 }
 
 type TeamMetrics struct {
@@ -1,10 +1,49 @@
 package models
 
+import "github.com/Parnishkaspb/avito/internal/apierror"
+
 type RequestTeamAddResponse struct {
 	TeamName string           `json:"team_name"`
 	Members  []RequestMembers `json:"members"`
 }
 
+func (r RequestTeamAddResponse) Validate() error {
+	if err := ValidateName("team_name", r.TeamName); err != nil {
+		return err
+	}
+
+	if len(r.Members) == 0 {
+		return apierror.Validation("validation.empty_list", map[string]string{"field": "members"})
+	}
+
+	seen := make(map[string]struct{}, len(r.Members))
+	for _, member := range r.Members {
+		if err := ValidateID("members.user_id", member.UserID); err != nil {
+			return err
+		}
+
+		if err := ValidateName("members.username", member.Username); err != nil {
+			return err
+		}
+
+		if _, ok := seen[member.UserID]; ok {
+			return apierror.Validation("validation.duplicate_member", map[string]string{"field": "members", "value": member.UserID})
+		}
+		seen[member.UserID] = struct{}{}
+	}
+
+	return nil
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 type UserActiveResponse struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
@@ -30,3 +69,13 @@ type StaticResponse struct {
 	TotalTeams int           `json:"total_teams"`
 	Teams      []TeamMetrics `json:"teams"`
 }
+
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
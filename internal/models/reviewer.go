@@ -0,0 +1,11 @@
+package models
+
+const (
+	ReviewerStrategyRandom      = "random"
+	ReviewerStrategyLeastLoaded = "least_loaded"
+)
+
+type ReviewerPolicy struct {
+	Count    int
+	Strategy string
+}
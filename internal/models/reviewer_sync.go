@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+)
+
+const (
+	ReviewerSyncPending = "pending"
+	ReviewerSyncSynced  = "synced"
+	ReviewerSyncFailed  = "failed"
+)
+
+type ReviewerSync struct {
+	PullRequestID    string     `json:"pull_request_id"`
+	Provider         string     `json:"provider"`
+	Status           string     `json:"status"`
+	Attempts         int        `json:"attempts"`
+	RemovedReviewers []string   `json:"removed_reviewers"`
+	NextAttemptAt    time.Time  `json:"next_attempt_at"`
+	LastAttemptAt    *time.Time `json:"last_attempt_at"`
+	LastError        *string    `json:"last_error"`
+	SyncedAt         *time.Time `json:"synced_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// PendingReviewerSync — PR, взятый в работу синхронизатором: текущие ревьюеры и снятые
+// с момента последней успешной синхронизации.
+type PendingReviewerSync struct {
+	PullRequestID    string
+	Provider         string
+	Revision         int
+	Attempts         int
+	Reviewers        []string
+	RemovedReviewers []string
+}
+
+type ReviewerSyncAttempt struct {
+	Synced     bool
+	Error      string
+	RetryAfter time.Duration
+}
+
+type TeamReviewerSync struct {
+	TeamName string `json:"team_name"`
+	Enabled  bool   `json:"enabled"`
+}
+
+type TeamReviewerSyncRequest struct {
+	TeamName string `json:"team_name"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+func (r TeamReviewerSyncRequest) Validate() error {
+	if err := ValidateName("team_name", r.TeamName); err != nil {
+		return err
+	}
+
+	if r.Enabled == nil {
+		return apierror.Validation("validation.required", map[string]string{"field": "enabled"})
+	}
+
+	return nil
+}
@@ -5,3 +5,16 @@ type User struct {
 	Username string
 	IsActive bool
 }
+
+type OIDCIdentity struct {
+	Subject string
+	Email   string
+	Name    string
+	Teams   []string
+}
+
+type UserIdentity struct {
+	UserID      string `json:"user_id"`
+	Email       string `json:"email,omitempty"`
+	OIDCSubject string `json:"oidc_subject,omitempty"`
+}
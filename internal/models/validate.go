@@ -0,0 +1,44 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+)
+
+const (
+	maxIDLength   = 128
+	maxNameLength = 255
+)
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/#@!-]*$`)
+
+func ValidateID(field, value string) error {
+	if value == "" {
+		return apierror.Validation("validation.required", map[string]string{"field": field})
+	}
+
+	if len(value) > maxIDLength {
+		return apierror.Validation("validation.too_long", map[string]string{"field": field, "max": strconv.Itoa(maxIDLength)})
+	}
+
+	if !idPattern.MatchString(value) {
+		return apierror.Validation("validation.invalid_format", map[string]string{"field": field})
+	}
+
+	return nil
+}
+
+func ValidateName(field, value string) error {
+	if value == "" {
+		return apierror.Validation("validation.required", map[string]string{"field": field})
+	}
+
+	if utf8.RuneCountInString(value) > maxNameLength {
+		return apierror.Validation("validation.too_long", map[string]string{"field": field, "max": strconv.Itoa(maxNameLength)})
+	}
+
+	return nil
+}
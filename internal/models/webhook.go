@@ -0,0 +1,174 @@
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+)
+
+const (
+	EventPullRequestCreated            = "pull_request.created"
+	EventPullRequestReviewersAssigned  = "pull_request.reviewers_assigned"
+	EventPullRequestReviewerReassigned = "pull_request.reviewer_reassigned"
+	EventPullRequestMerged             = "pull_request.merged"
+	EventUserDeactivated               = "user.deactivated"
+)
+
+var WebhookEventTypes = []string{
+	EventPullRequestCreated,
+	EventPullRequestReviewersAssigned,
+	EventPullRequestReviewerReassigned,
+	EventPullRequestMerged,
+	EventUserDeactivated,
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+const MaxWebhookSecretLength = 256
+
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	Secret     string    `json:"-"`
+}
+
+type WebhookCreateRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (r WebhookCreateRequest) Validate() error {
+	if r.URL == "" {
+		return apierror.Validation("validation.required", map[string]string{"field": "url"})
+	}
+
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apierror.Validation("validation.invalid_format", map[string]string{"field": "url"})
+	}
+
+	if len(r.Secret) > MaxWebhookSecretLength {
+		return apierror.Validation("validation.too_long", map[string]string{"field": "secret", "max": "256"})
+	}
+
+	if len(r.EventTypes) == 0 {
+		return apierror.Validation("validation.empty_list", map[string]string{"field": "event_types"})
+	}
+
+	seen := make(map[string]struct{}, len(r.EventTypes))
+	for _, eventType := range r.EventTypes {
+		if !slices.Contains(WebhookEventTypes, eventType) {
+			return apierror.Validation("validation.unknown_event", map[string]string{"field": "event_types", "value": eventType})
+		}
+		if _, ok := seen[eventType]; ok {
+			return apierror.Validation("validation.duplicate_member", map[string]string{"field": "event_types", "value": eventType})
+		}
+		seen[eventType] = struct{}{}
+	}
+
+	return nil
+}
+
+type WebhookCreateResponse struct {
+	Secret       string              `json:"secret"`
+	Subscription WebhookSubscription `json:"subscription"`
+}
+
+type WebhookDeleteRequest struct {
+	ID string `json:"id"`
+}
+
+func (r WebhookDeleteRequest) Validate() error {
+	return ValidateID("id", r.ID)
+}
+
+type WebhookRedeliverRequest struct {
+	ID int64 `json:"id"`
+}
+
+func (r WebhookRedeliverRequest) Validate() error {
+	if r.ID <= 0 {
+		return apierror.Validation("validation.required", map[string]string{"field": "id"})
+	}
+	return nil
+}
+
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	SubscriptionID string     `json:"subscription_id"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code"`
+	LastError      *string    `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionID string
+	Status         string
+	Cursor         int64
+	Limit          int
+}
+
+type WebhookDeliveryPage struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	NextCursor *int64            `json:"next_cursor"`
+}
+
+// PendingWebhook — доставка, взятая диспетчером в работу, вместе с событием и адресом подписки.
+type PendingWebhook struct {
+	DeliveryID int64
+	Attempts   int
+	URL        string
+	Secret     string
+	Event      WebhookEvent
+}
+
+type WebhookEvent struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type WebhookAttempt struct {
+	Delivered  bool
+	StatusCode int
+	Error      string
+	RetryAfter time.Duration
+}
+
+type PullRequestEvent struct {
+	PullRequestID     string     `json:"pull_request_id"`
+	PullRequestName   string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	Status            string     `json:"status"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	MergedAt          *time.Time `json:"merged_at,omitempty"`
+}
+
+type ReviewerReassignedEvent struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+type UserDeactivatedEvent struct {
+	UserID string `json:"user_id"`
+}
@@ -0,0 +1,178 @@
+// Package fakeidp is an in-process OpenID Connect provider for tests. It
+// serves discovery, JWKS and the token endpoint, and issues authorization
+// codes through Authorize instead of a login page.
+package fakeidp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+	"golang.org/x/oauth2"
+)
+
+const keyID = "fakeidp"
+
+type authRequest struct {
+	redirectURI string
+	nonce       string
+	challenge   string
+	claims      map[string]any
+}
+
+type Server struct {
+	URL      string
+	ClientID string
+
+	key       *rsa.PrivateKey
+	discovery *oidctest.Server
+
+	mu    sync.Mutex
+	codes map[string]authRequest
+}
+
+func New(t testing.TB, clientID string) *Server {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("fakeidp: generate key: %v", err)
+	}
+
+	s := &Server{
+		ClientID: clientID,
+		key:      key,
+		discovery: &oidctest.Server{
+			PublicKeys: []oidctest.PublicKey{{PublicKey: key.Public(), KeyID: keyID, Algorithm: gooidc.RS256}},
+		},
+		codes: make(map[string]authRequest),
+	}
+
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	s.URL = srv.URL
+	s.discovery.SetIssuer(srv.URL)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		s.serveToken(w, r)
+		return
+	}
+	s.discovery.ServeHTTP(w, r)
+}
+
+// Authorize plays the user approving the login at authURL and returns the
+// redirect back to the client with code and state set. The claims end up in
+// the ID token issued for that code.
+func (s *Server) Authorize(authURL string, claims map[string]any) (*url.URL, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+
+	switch {
+	case query.Get("client_id") != s.ClientID:
+		return nil, errors.New("fakeidp: unknown client_id")
+	case query.Get("response_type") != "code":
+		return nil, errors.New("fakeidp: response_type must be code")
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		return nil, errors.New("fakeidp: PKCE S256 challenge is required")
+	}
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirect.String() == "" {
+		return nil, errors.New("fakeidp: redirect_uri is required")
+	}
+
+	code := rand.Text()
+	s.mu.Lock()
+	s.codes[code] = authRequest{
+		redirectURI: redirect.String(),
+		nonce:       query.Get("nonce"),
+		challenge:   query.Get("code_challenge"),
+		claims:      claims,
+	}
+	s.mu.Unlock()
+
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	return redirect, nil
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "invalid_request")
+		return
+	}
+
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != s.ClientID {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	req, found := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	switch {
+	case !found, r.PostForm.Get("redirect_uri") != req.redirectURI:
+		tokenError(w, "invalid_grant")
+		return
+	case oauth2.S256ChallengeFromVerifier(r.PostForm.Get("code_verifier")) != req.challenge:
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   s.URL,
+		"aud":   s.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": req.nonce,
+	}
+	for k, v := range req.claims {
+		claims[k] = v
+	}
+
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     oidctest.SignIDToken(s.key, keyID, gooidc.RS256, string(raw)),
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
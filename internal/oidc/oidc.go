@@ -0,0 +1,223 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/models"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const maxPendingLogins = 10000
+
+var (
+	ErrInvalidState    = errors.New("oidc: unknown or expired state")
+	ErrTooManyLogins   = errors.New("oidc: too many pending logins")
+	ErrEmailUnverified = errors.New("oidc: email is not verified")
+	ErrMissingClaim    = errors.New("oidc: required claim is missing")
+)
+
+type pendingLogin struct {
+	nonce     string
+	verifier  string
+	expiresAt time.Time
+}
+
+type Client struct {
+	cfg config.OIDCConfig
+	now func() time.Time
+
+	providerMu sync.Mutex
+	provider   *gooidc.Provider
+
+	mu      sync.Mutex
+	pending map[string]pendingLogin
+}
+
+func New(cfg config.OIDCConfig) *Client {
+	return &Client{
+		cfg:     cfg,
+		now:     time.Now,
+		pending: make(map[string]pendingLogin),
+	}
+}
+
+func (c *Client) discover(ctx context.Context) (*gooidc.Provider, error) {
+	c.providerMu.Lock()
+	defer c.providerMu.Unlock()
+
+	if c.provider != nil {
+		return c.provider, nil
+	}
+
+	provider, err := gooidc.NewProvider(ctx, c.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	c.provider = provider
+	return provider, nil
+}
+
+func (c *Client) oauth2Config(provider *gooidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     c.cfg.ClientID,
+		ClientSecret: c.cfg.ClientSecret,
+		RedirectURL:  c.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       c.cfg.Scopes,
+	}
+}
+
+func (c *Client) AuthCodeURL(ctx context.Context) (string, error) {
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	if err := c.storePending(state, pendingLogin{nonce: nonce, verifier: verifier, expiresAt: c.now().Add(c.cfg.StateTTL)}); err != nil {
+		return "", err
+	}
+
+	return c.oauth2Config(provider).AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (c *Client) Exchange(ctx context.Context, state, code string) (models.OIDCIdentity, error) {
+	login, ok := c.takePending(state)
+	if !ok {
+		return models.OIDCIdentity{}, ErrInvalidState
+	}
+
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return models.OIDCIdentity{}, err
+	}
+
+	token, err := c.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(login.verifier))
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("oidc code exchange: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return models.OIDCIdentity{}, fmt.Errorf("oidc code exchange: %w: id_token", ErrMissingClaim)
+	}
+
+	idToken, err := provider.Verifier(&gooidc.Config{ClientID: c.cfg.ClientID, Now: c.now}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("oidc id token: %w", err)
+	}
+	if idToken.Nonce != login.nonce {
+		return models.OIDCIdentity{}, errors.New("oidc id token: nonce mismatch")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("oidc id token claims: %w", err)
+	}
+
+	return c.identity(idToken.Subject, claims)
+}
+
+func (c *Client) identity(subject string, claims map[string]any) (models.OIDCIdentity, error) {
+	identity := models.OIDCIdentity{Subject: subject}
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	if identity.Name == "" {
+		identity.Name, _ = claims["preferred_username"].(string)
+	}
+
+	if c.cfg.UserClaim == "email" {
+		if identity.Email == "" {
+			return models.OIDCIdentity{}, fmt.Errorf("%w: email", ErrMissingClaim)
+		}
+		// IdP, который не сообщает email_verified, не подтверждает адрес
+		if verified, _ := claims["email_verified"].(bool); !verified {
+			return models.OIDCIdentity{}, ErrEmailUnverified
+		}
+	}
+
+	for _, group := range stringList(claims[c.cfg.GroupsClaim]) {
+		team, ok := strings.CutPrefix(group, c.cfg.TeamPrefix)
+		if ok && team != "" {
+			identity.Teams = append(identity.Teams, team)
+		}
+	}
+
+	return identity, nil
+}
+
+func (c *Client) storePending(state string, login pendingLogin) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for key, p := range c.pending {
+		if !p.expiresAt.After(now) {
+			delete(c.pending, key)
+		}
+	}
+
+	if len(c.pending) >= maxPendingLogins {
+		return ErrTooManyLogins
+	}
+
+	c.pending[state] = login
+	return nil
+}
+
+func (c *Client) takePending(state string) (pendingLogin, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	login, ok := c.pending[state]
+	if !ok {
+		return pendingLogin{}, false
+	}
+	delete(c.pending, state)
+
+	return login, login.expiresAt.After(c.now())
+}
+
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,131 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/oidc/fakeidp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, userClaim string) (*Client, *fakeidp.Server) {
+	t.Helper()
+
+	idp := fakeidp.New(t, "avito")
+	return New(config.OIDCConfig{
+		Enabled:     true,
+		Issuer:      idp.URL,
+		ClientID:    "avito",
+		RedirectURL: "http://localhost:8080/auth/oidc/callback",
+		Scopes:      []string{"openid", "email"},
+		UserClaim:   userClaim,
+		StateTTL:    time.Minute,
+		GroupsClaim: "groups",
+		TeamPrefix:  "team:",
+	}), idp
+}
+
+func login(t *testing.T, c *Client, idp *fakeidp.Server, claims map[string]any) (state, code string) {
+	t.Helper()
+
+	authURL, err := c.AuthCodeURL(context.Background())
+	require.NoError(t, err)
+
+	redirect, err := idp.Authorize(authURL, claims)
+	require.NoError(t, err)
+	return redirect.Query().Get("state"), redirect.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	c, idp := newTestClient(t, "sub")
+
+	state, code := login(t, c, idp, map[string]any{
+		"sub":    "alice-sub",
+		"email":  "alice@example.com",
+		"name":   "Alice",
+		"groups": []string{"team:backend", "staff", "team:payments"},
+	})
+
+	identity, err := c.Exchange(context.Background(), state, code)
+	require.NoError(t, err)
+	assert.Equal(t, "alice-sub", identity.Subject)
+	assert.Equal(t, "alice@example.com", identity.Email)
+	assert.Equal(t, "Alice", identity.Name)
+	assert.Equal(t, []string{"backend", "payments"}, identity.Teams)
+
+	_, err = c.Exchange(context.Background(), state, code)
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
+
+func TestAuthCodeURL_UsesPKCE(t *testing.T) {
+	c, _ := newTestClient(t, "sub")
+
+	authURL, err := c.AuthCodeURL(context.Background())
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := u.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.NotEmpty(t, query.Get("code_challenge"))
+	assert.NotEmpty(t, query.Get("nonce"))
+	assert.NotEmpty(t, query.Get("state"))
+}
+
+func TestExchange_ExpiredState(t *testing.T) {
+	c, idp := newTestClient(t, "sub")
+	state, code := login(t, c, idp, map[string]any{"sub": "alice-sub"})
+
+	c.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	_, err := c.Exchange(context.Background(), state, code)
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
+
+func TestExchange_UnverifiedEmail(t *testing.T) {
+	c, idp := newTestClient(t, "email")
+	state, code := login(t, c, idp, map[string]any{
+		"sub":            "alice-sub",
+		"email":          "alice@example.com",
+		"email_verified": false,
+	})
+
+	_, err := c.Exchange(context.Background(), state, code)
+	assert.ErrorIs(t, err, ErrEmailUnverified)
+}
+
+func TestExchange_EmailVerifiedClaimMissing(t *testing.T) {
+	c, idp := newTestClient(t, "email")
+	state, code := login(t, c, idp, map[string]any{
+		"sub":   "alice-sub",
+		"email": "alice@example.com",
+	})
+
+	_, err := c.Exchange(context.Background(), state, code)
+	assert.ErrorIs(t, err, ErrEmailUnverified)
+}
+
+func TestExchange_VerifiedEmail(t *testing.T) {
+	c, idp := newTestClient(t, "email")
+	state, code := login(t, c, idp, map[string]any{
+		"sub":            "alice-sub",
+		"email":          "alice@example.com",
+		"email_verified": true,
+	})
+
+	identity, err := c.Exchange(context.Background(), state, code)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", identity.Email)
+}
+
+func TestExchange_RejectsTamperedCode(t *testing.T) {
+	c, idp := newTestClient(t, "sub")
+	state, _ := login(t, c, idp, map[string]any{"sub": "alice-sub"})
+
+	_, err := c.Exchange(context.Background(), state, "forged")
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+// Package queue — общий цикл фоновых обработчиков очередей в БД (доставка вебхуков,
+// синхронизация ревьюеров): задачи забираются пачками под lease и обрабатываются параллельно,
+// неудачные повторяются с экспоненциальной задержкой.
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Poller раз в PollInterval забирает до BatchSize задач через Claim и обрабатывает их через Handle.
+// Задача, не записанная до истечения Lease, снова попадает в выборку.
+type Poller[T any] struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Lease        time.Duration
+	Claim        func(ctx context.Context, limit int, lease time.Duration) ([]T, error)
+	Handle       func(ctx context.Context, item T)
+	Log          *slog.Logger
+}
+
+func (p Poller[T]) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// полная пачка — в очереди, скорее всего, есть ещё, не ждём следующего тика
+		for p.Poll(ctx) == p.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll обрабатывает одну пачку и возвращает её размер.
+func (p Poller[T]) Poll(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	items, err := p.Claim(ctx, p.BatchSize, p.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.Log.ErrorContext(ctx, "failed to claim queue items", slog.Any("error", err))
+		}
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Handle(ctx, item)
+		}()
+	}
+	wg.Wait()
+
+	return len(items)
+}
+
+// Retry — политика повторов неудачных задач.
+type Retry struct {
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// Next возвращает задержку перед следующей попыткой после attempts неудачных или false,
+// если попытки исчерпаны.
+func (r Retry) Next(attempts int) (time.Duration, bool) {
+	if attempts >= r.MaxAttempts {
+		return 0, false
+	}
+	return Backoff(attempts, r.BackoffBase, r.BackoffMax), true
+}
+
+func Backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return min(delay, maxDelay)
+}
@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueue struct {
+	mu      sync.Mutex
+	pending []int
+	handled []int
+	err     error
+}
+
+func (f *fakeQueue) claim(_ context.Context, limit int, _ time.Duration) ([]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	n := min(limit, len(f.pending))
+	claimed := f.pending[:n]
+	f.pending = f.pending[n:]
+	return claimed, nil
+}
+
+func (f *fakeQueue) handle(_ context.Context, item int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handled = append(f.handled, item)
+}
+
+func newPoller(q *fakeQueue) Poller[int] {
+	return Poller[int]{
+		PollInterval: time.Hour,
+		BatchSize:    2,
+		Lease:        time.Second,
+		Claim:        q.claim,
+		Handle:       q.handle,
+		Log:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestPoller_Poll(t *testing.T) {
+	q := &fakeQueue{pending: []int{1, 2, 3}}
+	p := newPoller(q)
+
+	assert.Equal(t, 2, p.Poll(context.Background()))
+	assert.ElementsMatch(t, []int{1, 2}, q.handled)
+
+	q.err = errors.New("db down")
+	assert.Zero(t, p.Poll(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.err = nil
+	assert.Zero(t, p.Poll(ctx))
+	assert.Len(t, q.handled, 2)
+}
+
+func TestPoller_RunDrainsFullBatches(t *testing.T) {
+	q := &fakeQueue{pending: []int{1, 2, 3, 4, 5}}
+	p := newPoller(q)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.handled) == 5
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestRetry_Next(t *testing.T) {
+	r := Retry{MaxAttempts: 3, BackoffBase: time.Second, BackoffMax: time.Minute}
+
+	delay, ok := r.Next(1)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, delay)
+
+	delay, ok = r.Next(2)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, delay)
+
+	_, ok = r.Next(3)
+	assert.False(t, ok)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, time.Minute},
+		{100, time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Backoff(tt.attempt, 10*time.Second, time.Minute), "attempt %d", tt.attempt)
+	}
+}
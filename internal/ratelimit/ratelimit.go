@@ -0,0 +1,232 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"golang.org/x/time/rate"
+)
+
+const (
+	idleTTL         = 10 * time.Minute
+	cleanupInterval = time.Minute
+
+	// ipRoute — маршрут бакетов общего лимита по IP
+	ipRoute = "*"
+)
+
+type Limiter struct {
+	mu          sync.Mutex
+	enabled     bool
+	def         config.RateLimitRule
+	perIP       config.RateLimitRule
+	routes      map[string]config.RateLimitRule
+	trusted     []*net.IPNet
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	route    string
+	rule     config.RateLimitRule
+	lastSeen time.Time
+}
+
+func New(cfg config.RateLimitConfig) *Limiter {
+	l := &Limiter{now: time.Now}
+	l.Update(cfg)
+	return l
+}
+
+func (l *Limiter) Update(cfg config.RateLimitConfig) {
+	routes := make(map[string]config.RateLimitRule, len(cfg.Routes))
+	for route, rule := range cfg.Routes {
+		routes[route] = rule
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.enabled = cfg.Enabled
+	l.def = cfg.Default
+	l.perIP = cfg.PerIP
+	l.routes = routes
+	l.trusted = parseNetworks(cfg.TrustedProxies)
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+		l.lastCleanup = l.now()
+	}
+
+	// перечитывание конфига не должно возвращать клиентам полный burst: сбрасываются
+	// только бакеты маршрутов, у которых изменилось правило
+	for id, b := range l.buckets {
+		if b.rule != l.ruleFor(b.route) {
+			delete(l.buckets, id)
+		}
+	}
+}
+
+func (l *Limiter) Allow(route, key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.allow(route, key, l.ruleFor(route))
+}
+
+// AllowIP проверяет общий для всех маршрутов лимит клиента по IP.
+func (l *Limiter) AllowIP(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.allow(ipRoute, "ip:"+ip, l.perIP)
+}
+
+func (l *Limiter) ruleFor(route string) config.RateLimitRule {
+	if route == ipRoute {
+		return l.perIP
+	}
+	if rule, ok := l.routes[route]; ok {
+		return rule
+	}
+	return l.def
+}
+
+func (l *Limiter) allow(route, key string, rule config.RateLimitRule) (bool, time.Duration) {
+	if !l.enabled || rule.RPS <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	l.cleanup(now)
+
+	id := route + " " + key
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(rule.RPS), rule.Burst), route: route, rule: rule}
+		l.buckets[id] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	for id, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTTL {
+			delete(l.buckets, id)
+		}
+	}
+}
+
+func (l *Limiter) ClientIP(r *http.Request) string {
+	l.mu.Lock()
+	trusted := l.trusted
+	l.mu.Unlock()
+
+	remote := remoteIP(r.RemoteAddr)
+	if !isTrusted(remote, trusted) {
+		return remote
+	}
+
+	forwarded := r.Header.Values("X-Forwarded-For")
+	var hops []string
+	for _, value := range forwarded {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			return remote
+		}
+		if !isTrusted(ip.String(), trusted) {
+			return ip.String()
+		}
+	}
+
+	if len(hops) > 0 {
+		if ip := net.ParseIP(hops[0]); ip != nil {
+			return ip.String()
+		}
+	}
+
+	return remote
+}
+
+func RetryAfterSeconds(delay time.Duration) int {
+	return int(math.Max(1, math.Ceil(delay.Seconds())))
+}
+
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+func isTrusted(value string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNetworks(values []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if _, network, err := net.ParseCIDR(value); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+
+		ip := net.ParseIP(value)
+		if ip == nil {
+			continue
+		}
+
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks
+}
@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter(cfg config.RateLimitConfig) (*Limiter, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := &Limiter{now: func() time.Time { return now }}
+	l.Update(cfg)
+	return l, &now
+}
+
+func TestLimiter_BurstThenRefill(t *testing.T) {
+	l, now := newTestLimiter(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{RPS: 1, Burst: 2},
+	})
+
+	ok, _ := l.Allow("/login", "ip:10.0.0.1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("/login", "ip:10.0.0.1")
+	assert.True(t, ok)
+
+	ok, retry := l.Allow("/login", "ip:10.0.0.1")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retry)
+
+	ok, _ = l.Allow("/login", "ip:10.0.0.2")
+	assert.True(t, ok, "other clients have their own bucket")
+
+	*now = now.Add(time.Second)
+	ok, _ = l.Allow("/login", "ip:10.0.0.1")
+	assert.True(t, ok)
+}
+
+func TestLimiter_PerRouteRules(t *testing.T) {
+	l, _ := newTestLimiter(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{RPS: 100, Burst: 100},
+		Routes: map[string]config.RateLimitRule{
+			"/pullRequest/create": {RPS: 1, Burst: 1},
+			"/metrics":            {RPS: 0},
+		},
+	})
+
+	ok, _ := l.Allow("/pullRequest/create", "user:u1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("/pullRequest/create", "user:u1")
+	assert.False(t, ok)
+
+	ok, _ = l.Allow("/team/get", "user:u1")
+	assert.True(t, ok, "routes do not share buckets")
+
+	for i := 0; i < 1000; i++ {
+		ok, _ = l.Allow("/metrics", "ip:10.0.0.1")
+		assert.True(t, ok)
+	}
+}
+
+func TestLimiter_AllowIPSharedAcrossRoutes(t *testing.T) {
+	l, _ := newTestLimiter(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{RPS: 100, Burst: 100},
+		PerIP:   config.RateLimitRule{RPS: 1, Burst: 2},
+	})
+
+	ok, _ := l.AllowIP("10.0.0.1")
+	assert.True(t, ok)
+	ok, _ = l.AllowIP("10.0.0.1")
+	assert.True(t, ok)
+	ok, _ = l.AllowIP("10.0.0.1")
+	assert.False(t, ok)
+
+	ok, _ = l.AllowIP("10.0.0.2")
+	assert.True(t, ok)
+	ok, _ = l.Allow("/team/get", "ip:10.0.0.1")
+	assert.True(t, ok, "per-IP bucket does not consume route buckets")
+}
+
+func TestLimiter_UpdateKeepsUnchangedBuckets(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{RPS: 1, Burst: 1},
+		PerIP:   config.RateLimitRule{RPS: 1, Burst: 1},
+		Routes:  map[string]config.RateLimitRule{"/pullRequest/create": {RPS: 1, Burst: 1}},
+	}
+	l, _ := newTestLimiter(cfg)
+
+	l.Allow("/team/get", "user:u1")
+	l.Allow("/pullRequest/create", "user:u1")
+	l.AllowIP("10.0.0.1")
+
+	l.Update(cfg)
+
+	ok, _ := l.Allow("/team/get", "user:u1")
+	assert.False(t, ok, "reload with the same config keeps the client throttled")
+	ok, _ = l.Allow("/pullRequest/create", "user:u1")
+	assert.False(t, ok)
+	ok, _ = l.AllowIP("10.0.0.1")
+	assert.False(t, ok)
+
+	cfg.Routes = map[string]config.RateLimitRule{"/pullRequest/create": {RPS: 1, Burst: 5}}
+	l.Update(cfg)
+
+	ok, _ = l.Allow("/pullRequest/create", "user:u1")
+	assert.True(t, ok, "changed rule starts a new bucket")
+	ok, _ = l.Allow("/team/get", "user:u1")
+	assert.False(t, ok)
+	ok, _ = l.AllowIP("10.0.0.1")
+	assert.False(t, ok)
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	l, _ := newTestLimiter(config.RateLimitConfig{Default: config.RateLimitRule{RPS: 1, Burst: 1}})
+
+	for i := 0; i < 10; i++ {
+		ok, _ := l.Allow("/login", "ip:10.0.0.1")
+		assert.True(t, ok)
+	}
+}
+
+func TestLimiter_CleanupIdleBuckets(t *testing.T) {
+	l, now := newTestLimiter(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{RPS: 1, Burst: 1},
+	})
+
+	l.Allow("/login", "ip:10.0.0.1")
+	assert.Len(t, l.buckets, 1)
+
+	*now = now.Add(idleTTL + cleanupInterval)
+	l.Allow("/login", "ip:10.0.0.2")
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestLimiter_ClientIP(t *testing.T) {
+	l := New(config.RateLimitConfig{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}})
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{name: "direct client", remote: "203.0.113.5:4000", want: "203.0.113.5"},
+		{name: "untrusted proxy header ignored", remote: "203.0.113.5:4000", forwarded: "1.2.3.4", want: "203.0.113.5"},
+		{name: "trusted proxy", remote: "10.1.2.3:4000", forwarded: "198.51.100.7", want: "198.51.100.7"},
+		{name: "chain of trusted proxies", remote: "10.1.2.3:4000", forwarded: "1.2.3.4, 198.51.100.7, 192.168.1.1", want: "198.51.100.7"},
+		{name: "all hops trusted", remote: "10.1.2.3:4000", forwarded: "10.0.0.9, 10.0.0.8", want: "10.0.0.9"},
+		{name: "garbage in header", remote: "10.1.2.3:4000", forwarded: "not-an-ip", want: "10.1.2.3"},
+		{name: "trusted proxy without header", remote: "10.1.2.3:4000", want: "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			assert.Equal(t, tt.want, l.ClientIP(r))
+		})
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, 1, RetryAfterSeconds(0))
+	assert.Equal(t, 1, RetryAfterSeconds(300*time.Millisecond))
+	assert.Equal(t, 3, RetryAfterSeconds(2100*time.Millisecond))
+}
@@ -0,0 +1,151 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+)
+
+// ApplyFunc проверяет новый конфиг и возвращает функцию, которая его применяет. Применение
+// начинается, только когда проверки всех обработчиков прошли.
+type ApplyFunc func(cfg *config.Config) (apply func(), err error)
+
+type Reloader struct {
+	path     string
+	interval time.Duration
+	log      *slog.Logger
+
+	signals chan os.Signal
+
+	mu      sync.Mutex
+	current *config.Config
+	apply   []ApplyFunc
+	modTime time.Time
+}
+
+func New(path string, current *config.Config, log *slog.Logger) *Reloader {
+	if log == nil {
+		log = slog.Default()
+	}
+
+	r := &Reloader{
+		path:     path,
+		interval: current.Reload.WatchInterval,
+		log:      log.With(slog.String("component", "reload")),
+		signals:  make(chan os.Signal, 1),
+		current:  current,
+	}
+	r.modTime = r.fileModTime()
+	// без обработчика SIGHUP завершает процесс, поэтому подписываемся сразу, а не в Run:
+	// сигнал, пришедший во время запуска остальных компонентов, обработается после старта Run
+	signal.Notify(r.signals, syscall.SIGHUP)
+
+	return r
+}
+
+func (r *Reloader) OnReload(fn ApplyFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apply = append(r.apply, fn)
+}
+
+func (r *Reloader) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.Load(r.path)
+	if err != nil {
+		return fmt.Errorf("config reload rejected: %w", err)
+	}
+
+	merged, ignored := r.current.WithReloadable(next)
+	for _, field := range ignored {
+		r.log.Warn("config field requires restart, change ignored", slog.String("field", field))
+	}
+
+	applies := make([]func(), 0, len(r.apply))
+	var errs []error
+	for _, fn := range r.apply {
+		apply, err := fn(merged)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		applies = append(applies, apply)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("config reload rejected: %w", errors.Join(errs...))
+	}
+
+	for _, apply := range applies {
+		apply()
+	}
+
+	r.current = merged
+	r.log.Info("config reloaded",
+		slog.Int("reviewers_count", merged.Reviewers.Count),
+		slog.String("reviewers_strategy", merged.Reviewers.Strategy),
+		slog.String("log_level", merged.Log.Level),
+	)
+
+	return nil
+}
+
+func (r *Reloader) Run(ctx context.Context) error {
+	defer signal.Stop(r.signals)
+
+	var tick <-chan time.Time
+	if r.interval > 0 && r.path != "" {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.signals:
+			r.log.Info("SIGHUP received, reloading config")
+			r.reload()
+		case <-tick:
+			if modTime := r.fileModTime(); !modTime.IsZero() && !modTime.Equal(r.modTime) {
+				r.modTime = modTime
+				r.log.Info("config file changed, reloading config")
+				r.reload()
+			}
+		}
+	}
+}
+
+func (r *Reloader) reload() {
+	if err := r.Reload(); err != nil {
+		r.log.Error("config reload failed", slog.Any("error", err))
+	}
+}
+
+func (r *Reloader) fileModTime() time.Time {
+	if r.path == "" {
+		return time.Time{}
+	}
+
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
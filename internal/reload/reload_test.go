@@ -0,0 +1,206 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseConfig = `
+server:
+  port: 8080
+postgresql:
+  user: user
+  password: password
+  host: localhost
+  port: 5432
+  db: avito
+jwt:
+  jwt_secret: "0123456789abcdef-test"
+reviewers:
+  count: 2
+  strategy: random
+`
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func newTestReloader(t *testing.T, content string) (*Reloader, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, content)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	return New(path, cfg, slog.New(slog.NewTextHandler(io.Discard, nil))), path
+}
+
+func TestReload_AppliesReloadableFields(t *testing.T) {
+	r, path := newTestReloader(t, baseConfig)
+
+	var applied *config.Config
+	r.OnReload(func(cfg *config.Config) (func(), error) {
+		return func() { applied = cfg }, nil
+	})
+
+	updated := strings.Replace(baseConfig, "count: 2", "count: 3", 1)
+	updated = strings.Replace(updated, "strategy: random", "strategy: least_loaded", 1)
+	updated = strings.Replace(updated, "port: 8080", "port: 9090", 1)
+	writeConfig(t, path, updated)
+
+	require.NoError(t, r.Reload())
+	require.NotNil(t, applied)
+
+	assert.Equal(t, 3, applied.Reviewers.Count)
+	assert.Equal(t, "least_loaded", applied.Reviewers.Strategy)
+	assert.Equal(t, 8080, applied.Server.Port)
+	assert.Equal(t, applied, r.Current())
+}
+
+func TestReload_RejectsInvalidConfig(t *testing.T) {
+	r, path := newTestReloader(t, baseConfig)
+
+	called := false
+	r.OnReload(func(cfg *config.Config) (func(), error) {
+		called = true
+		return func() {}, nil
+	})
+
+	writeConfig(t, path, strings.Replace(baseConfig, "strategy: random", "strategy: round_robin", 1))
+
+	assert.Error(t, r.Reload())
+	assert.False(t, called)
+	assert.Equal(t, "random", r.Current().Reviewers.Strategy)
+}
+
+func TestReload_FailedHookAppliesNothing(t *testing.T) {
+	r, path := newTestReloader(t, baseConfig)
+
+	applied := false
+	r.OnReload(func(cfg *config.Config) (func(), error) {
+		return func() { applied = true }, nil
+	})
+	r.OnReload(func(cfg *config.Config) (func(), error) {
+		return nil, errors.New("unsupported value")
+	})
+
+	writeConfig(t, path, strings.Replace(baseConfig, "count: 2", "count: 3", 1))
+
+	assert.ErrorContains(t, r.Reload(), "unsupported value")
+	assert.False(t, applied)
+	assert.Equal(t, 2, r.Current().Reviewers.Count)
+}
+
+func TestRun_HandlesSIGHUPBeforeRun(t *testing.T) {
+	r, path := newTestReloader(t, baseConfig)
+
+	var mu sync.Mutex
+	count := 0
+	r.OnReload(func(cfg *config.Config) (func(), error) {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			count = cfg.Reviewers.Count
+		}, nil
+	})
+
+	writeConfig(t, path, strings.Replace(baseConfig, "count: 2", "count: 6", 1))
+	// без подписки в New сигнал до запуска Run завершил бы тестовый процесс
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return count == 6
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestRun_ReloadsOnSIGHUP(t *testing.T) {
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	r, path := newTestReloader(t, baseConfig)
+
+	var mu sync.Mutex
+	count := 0
+	r.OnReload(func(cfg *config.Config) (func(), error) {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			count = cfg.Reviewers.Count
+		}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+
+	writeConfig(t, path, strings.Replace(baseConfig, "count: 2", "count: 4", 1))
+
+	require.Eventually(t, func() bool {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGHUP)
+		mu.Lock()
+		defer mu.Unlock()
+		return count == 4
+	}, time.Second, 20*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestRun_ReloadsOnFileChange(t *testing.T) {
+	r, path := newTestReloader(t, baseConfig+"reload:\n  watch_interval: 10ms\n")
+
+	var mu sync.Mutex
+	count := 0
+	r.OnReload(func(cfg *config.Config) (func(), error) {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			count = cfg.Reviewers.Count
+		}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+
+	updated := strings.Replace(baseConfig, "count: 2", "count: 5", 1) + "reload:\n  watch_interval: 10ms\n"
+	writeConfig(t, path, updated)
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return count == 5
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
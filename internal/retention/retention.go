@@ -0,0 +1,66 @@
+// Package retention периодически удаляет устаревшие записи таблиц, которые растут без ограничений
+// (журнал аудита, обработанные доставки вебхуков code host).
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PurgeFunc удаляет записи старше olderThan и возвращает их количество.
+type PurgeFunc func(ctx context.Context, olderThan time.Time) (int64, error)
+
+type Retention struct {
+	purge    PurgeFunc
+	keep     time.Duration
+	interval time.Duration
+	log      *slog.Logger
+	now      func() time.Time
+}
+
+// New создаёт задачу очистки; keep = 0 — хранить бессрочно.
+func New(component string, keep, interval time.Duration, purge PurgeFunc, log *slog.Logger) *Retention {
+	return &Retention{
+		purge:    purge,
+		keep:     keep,
+		interval: interval,
+		log:      log.With(slog.String("component", component)),
+		now:      time.Now,
+	}
+}
+
+func (r *Retention) Run(ctx context.Context) error {
+	if r.keep == 0 {
+		r.log.InfoContext(ctx, "retention disabled")
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Retention) Purge(ctx context.Context) {
+	deleted, err := r.purge(ctx, r.now().Add(-r.keep))
+	if err != nil {
+		if ctx.Err() == nil {
+			r.log.ErrorContext(ctx, "purge failed", slog.Any("error", err))
+		}
+		return
+	}
+
+	if deleted > 0 {
+		r.log.InfoContext(ctx, "expired records purged", slog.Int64("deleted", deleted), slog.Duration("retention", r.keep))
+	}
+}
@@ -0,0 +1,71 @@
+package retention
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePurger struct {
+	mu    sync.Mutex
+	calls []time.Time
+}
+
+func (f *fakePurger) purge(_ context.Context, olderThan time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, olderThan)
+	return 1, nil
+}
+
+func (f *fakePurger) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestRetention_PurgesOlderThanRetention(t *testing.T) {
+	db := &fakePurger{}
+	r := New("audit", 24*time.Hour, time.Hour, db.purge, discardLogger())
+
+	now := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.Purge(context.Background())
+	require.Len(t, db.calls, 1)
+	assert.Equal(t, now.Add(-24*time.Hour), db.calls[0])
+}
+
+func TestRetention_RunsPeriodically(t *testing.T) {
+	db := &fakePurger{}
+	r := New("audit", time.Hour, 10*time.Millisecond, db.purge, discardLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+
+	assert.Eventually(t, func() bool { return db.count() >= 3 }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestRetention_Disabled(t *testing.T) {
+	db := &fakePurger{}
+	r := New("audit", 0, time.Millisecond, db.purge, discardLogger())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, r.Run(ctx))
+	assert.Zero(t, db.count())
+}
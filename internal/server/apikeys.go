@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/logger"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const (
+	apiKeyHeader       = "X-API-Key"
+	apiKeyTokenPrefix  = "avk_"
+	apiKeyPrefixLength = 8
+	apiKeySecretBytes  = 32
+)
+
+const apiKeyIDKey contextKey = "apiKeyID"
+
+func generateAPIKey() (plain, prefix string, err error) {
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	plain = apiKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return plain, plain[:len(apiKeyTokenPrefix)+apiKeyPrefixLength], nil
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func routeScope(r *http.Request) string {
+	return strings.ReplaceAll(strings.TrimPrefix(r.Pattern, "/"), "/", ":")
+}
+
+func (s *Server) apiKeyAuth(w http.ResponseWriter, r *http.Request, plain string, next http.HandlerFunc) {
+	if !strings.HasPrefix(plain, apiKeyTokenPrefix) {
+		s.writeError(w, r, apierror.ErrInvalidKey)
+		return
+	}
+
+	key, err := s.db.AuthenticateAPIKey(r.Context(), hashAPIKey(plain))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			s.writeError(w, r, apierror.ErrInvalidKey)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if !key.HasScope(routeScope(r)) {
+		s.writeError(w, r, apierror.ErrScopeDenied)
+		return
+	}
+
+	ctx := r.Context()
+	ctx = context.WithValue(ctx, userIDKey, key.ServiceAccount)
+	ctx = context.WithValue(ctx, userNameKey, key.Name)
+	ctx = context.WithValue(ctx, userRoleKey, key.ServiceAccountAdmin != nil && *key.ServiceAccountAdmin)
+	ctx = context.WithValue(ctx, apiKeyIDKey, key.ID)
+	ctx = logger.WithAttrs(ctx, slog.String("user_id", key.ServiceAccount), slog.String("api_key_id", key.ID))
+	r = r.WithContext(ctx)
+	setRequestUser(ctx, key.ServiceAccount)
+
+	next(w, r)
+}
+
+func (s *Server) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.APIKeyCreateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	plain, prefix, err := generateAPIKey()
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	createdBy, _ := r.Context().Value(userIDKey).(string)
+
+	var key models.APIKey
+	err = s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		key, err = s.db.CreateAPIKey(ctx, models.APIKey{
+			Name:           req.Name,
+			ServiceAccount: req.ServiceAccount,
+			Prefix:         prefix,
+			Scopes:         req.Scopes,
+			CreatedBy:      createdBy,
+			ExpiresAt:      req.ExpiresAt,
+			Hash:           hashAPIKey(plain),
+
+			ServiceAccountAdmin: req.ServiceAccountAdmin,
+		})
+		if err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: key.ID, after: key}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "api key created",
+		slog.String("api_key_id", key.ID), slog.String("service_account", key.ServiceAccount))
+
+	writeJSON(w, http.StatusCreated, models.APIKeyCreateResponse{Key: plain, APIKey: key})
+}
+
+func (s *Server) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.db.ListAPIKeys(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"api_keys": keys,
+	})
+}
+
+func (s *Server) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.APIKeyRevokeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var key models.APIKey
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		before, err := s.db.GetAPIKey(ctx, req.ID)
+		if err != nil {
+			return auditChange{}, err
+		}
+		if key, err = s.db.RevokeAPIKey(ctx, req.ID); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: key.ID, before: before, after: key}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "api key revoked", slog.String("api_key_id", key.ID))
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"api_key": key,
+	})
+}
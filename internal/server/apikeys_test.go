@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAPIKeyDB struct {
+	fakeDB
+	mu       sync.Mutex
+	keys     map[string]models.APIKey
+	users    map[string]bool // обычные пользователи
+	accounts map[string]bool // сервисные аккаунты и их роль
+	merged   []string
+}
+
+func (f *fakeAPIKeyDB) CreateAPIKey(_ context.Context, key models.APIKey) (models.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.users[key.ServiceAccount] {
+		return models.APIKey{}, database.ErrNotServiceAccount
+	}
+	if key.ServiceAccountAdmin != nil {
+		f.accounts[key.ServiceAccount] = *key.ServiceAccountAdmin
+	}
+	admin := f.accounts[key.ServiceAccount]
+
+	key.ID = "key-" + key.Prefix
+	key.CreatedAt = time.Now()
+	key.ServiceAccountAdmin = &admin
+	f.keys[key.Hash] = key
+	return key, nil
+}
+
+func (f *fakeAPIKeyDB) GetAPIKey(_ context.Context, id string) (models.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, key := range f.keys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, database.ErrNotFound
+}
+
+func (f *fakeAPIKeyDB) RevokeAPIKey(_ context.Context, id string) (models.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for hash, key := range f.keys {
+		if key.ID == id {
+			now := time.Now()
+			key.RevokedAt = &now
+			f.keys[hash] = key
+			return key, nil
+		}
+	}
+	return models.APIKey{}, database.ErrNotFound
+}
+
+func (f *fakeAPIKeyDB) AuthenticateAPIKey(_ context.Context, hash string) (models.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key, ok := f.keys[hash]
+	if !ok || key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now())) {
+		return models.APIKey{}, database.ErrNotFound
+	}
+
+	now := time.Now()
+	key.LastUsedAt = &now
+	f.keys[hash] = key
+
+	admin := f.accounts[key.ServiceAccount]
+	key.ServiceAccountAdmin = &admin
+	return key, nil
+}
+
+func (f *fakeAPIKeyDB) MergePullRequest(_ context.Context, prID string) (models.PullRequest, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.merged = append(f.merged, prID)
+	return models.PullRequest{PullRequestID: prID, Status: "MERGED"}, true, nil
+}
+
+func newAPIKeyTestServer(t *testing.T) (*Server, *fakeAPIKeyDB) {
+	t.Helper()
+
+	db := &fakeAPIKeyDB{
+		keys:     map[string]models.APIKey{},
+		users:    map[string]bool{"u1": true},
+		accounts: map[string]bool{},
+	}
+	return newTestServer(t, db), db
+}
+
+func createTestAPIKey(t *testing.T, s *Server, body string) models.APIKeyCreateResponse {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/apiKeys/create", body, true))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp models.APIKeyCreateResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	return resp
+}
+
+func apiKeyRequest(method, target, body, key string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(apiKeyHeader, key)
+	return req
+}
+
+func TestAPIKey_ScopedAccess(t *testing.T) {
+	s, db := newAPIKeyTestServer(t)
+
+	created := createTestAPIKey(t, s, `{"name":"ci","service_account":"ci-bot","scopes":["pullRequest:merge"],"service_account_admin":true}`)
+	assert.True(t, strings.HasPrefix(created.Key, apiKeyTokenPrefix))
+	assert.True(t, strings.HasPrefix(created.Key, created.APIKey.Prefix))
+	assert.Equal(t, "u1", created.APIKey.CreatedBy)
+	assert.NotContains(t, db.keys, created.Key)
+	assert.Contains(t, db.keys, hashAPIKey(created.Key))
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, apiKeyRequest(http.MethodPost, "/pullRequest/merge", `{"pull_request_id":"pr-1"}`, created.Key))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []string{"pr-1"}, db.merged)
+	assert.NotNil(t, db.keys[hashAPIKey(created.Key)].LastUsedAt)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, apiKeyRequest(http.MethodPost, "/pullRequest/create", `{}`, created.Key))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, constants.FORBIDDEN, decodeErrorCode(t, rec))
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, apiKeyRequest(http.MethodPost, "/apiKeys/create", `{}`, created.Key))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAPIKey_Rejected(t *testing.T) {
+	s, db := newAPIKeyTestServer(t)
+
+	expired := time.Now().Add(-time.Minute)
+	created := createTestAPIKey(t, s, `{"name":"ci","service_account":"ci-bot","scopes":["pullRequest:merge"]}`)
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, apiKeyRequest(http.MethodPost, "/pullRequest/merge", `{"pull_request_id":"pr-1"}`, apiKeyTokenPrefix+"unknown"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/apiKeys/revoke", `{"id":"`+created.APIKey.ID+`"}`, true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	revoked := db.entries[len(db.entries)-1]
+	assert.Equal(t, "apiKeys:revoke", revoked.Action)
+	var before, after models.APIKey
+	require.NoError(t, json.Unmarshal(revoked.Before, &before))
+	require.NoError(t, json.Unmarshal(revoked.After, &after))
+	assert.Nil(t, before.RevokedAt)
+	assert.NotNil(t, after.RevokedAt)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, apiKeyRequest(http.MethodPost, "/pullRequest/merge", `{"pull_request_id":"pr-1"}`, created.Key))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, constants.UNAUTHORIZED, decodeErrorCode(t, rec))
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/apiKeys/create",
+		`{"name":"ci","service_account":"ci-bot","scopes":["pullRequest:merge"],"expires_at":"`+expired.Format(time.RFC3339)+`"}`, true))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/apiKeys/create",
+		`{"name":"ci","service_account":"ci-bot","scopes":["team:add"]}`, true))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/apiKeys/create",
+		`{"name":"ci","service_account":"ci-bot","scopes":["pullRequest:merge"]}`, false))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAPIKey_ServiceAccountOnly(t *testing.T) {
+	s, db := newAPIKeyTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/apiKeys/create",
+		`{"name":"ci","service_account":"u1","scopes":["pullRequest:merge"],"service_account_admin":true}`, true))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, constants.VALIDATION_ERROR, decodeErrorCode(t, rec))
+	assert.Empty(t, db.keys)
+
+	// роль берётся из сервисного аккаунта: без прав администратора merge недоступен
+	created := createTestAPIKey(t, s, `{"name":"ci","service_account":"ci-bot","scopes":["pullRequest:merge"]}`)
+	require.NotNil(t, created.APIKey.ServiceAccountAdmin)
+	assert.False(t, *created.APIKey.ServiceAccountAdmin)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, apiKeyRequest(http.MethodPost, "/pullRequest/merge", `{"pull_request_id":"pr-1"}`, created.Key))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, db.merged)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// auditChange — изменение для журнала аудита; пустой target значит, что менять было нечего
+// и запись не нужна.
+type auditChange struct {
+	target string
+	before any
+	after  any
+}
+
+// audited выполняет изменение и запись о нём в журнал аудита в одной транзакции: если журнал
+// записать не удалось, изменение откатывается и запрос завершается ошибкой.
+func (s *Server) audited(r *http.Request, change func(ctx context.Context) (auditChange, error)) error {
+	ctx := r.Context()
+
+	entry := models.AuditEntry{
+		Action:    routeScope(r),
+		RequestID: getRequestID(ctx),
+	}
+	entry.Actor, _ = ctx.Value(userIDKey).(string)
+	entry.APIKeyID, _ = ctx.Value(apiKeyIDKey).(string)
+
+	return s.auditedAs(ctx, entry, change)
+}
+
+func (s *Server) auditedAs(ctx context.Context, entry models.AuditEntry, change func(ctx context.Context) (auditChange, error)) error {
+	return s.db.InTx(ctx, func(ctx context.Context) error {
+		c, err := change(ctx)
+		if err != nil || c.target == "" {
+			return err
+		}
+
+		entry.Target = c.target
+		if c.before != nil {
+			if entry.Before, err = json.Marshal(c.before); err != nil {
+				return fmt.Errorf("encode audit snapshot: %w", err)
+			}
+		}
+		if c.after != nil {
+			if entry.After, err = json.Marshal(c.after); err != nil {
+				return fmt.Errorf("encode audit snapshot: %w", err)
+			}
+		}
+
+		return s.db.WriteAuditEntry(ctx, entry)
+	})
+}
+
+func (s *Server) pullRequestSnapshot(ctx context.Context, prID string) any {
+	pr, err := s.db.PullRequestFullInformation(ctx, prID)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			s.log.WarnContext(ctx, "failed to load audit snapshot", slog.String("pull_request_id", prID), slog.Any("error", err))
+		}
+		return nil
+	}
+	return pr
+}
+
+func (s *Server) auditHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	entries, err := s.db.ListAuditEntries(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	page := models.AuditPage{Entries: entries}
+	if len(entries) == filter.Limit {
+		next := entries[len(entries)-1].ID
+		page.NextCursor = &next
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Target: query.Get("target"),
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		value := query.Get(p.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return models.AuditFilter{}, apierror.Validation("validation.invalid_format", map[string]string{"field": p.name})
+		}
+		*p.dst = &t
+	}
+
+	var err error
+	if filter.Cursor, filter.Limit, err = parsePage(query); err != nil {
+		return models.AuditFilter{}, err
+	}
+
+	return filter, nil
+}
+
+func parsePage(query url.Values) (cursor int64, limit int, err error) {
+	limit = defaultPageSize
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor <= 0 {
+			return 0, 0, apierror.Validation("validation.invalid_format", map[string]string{"field": "cursor"})
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxPageSize {
+			return 0, 0, apierror.Validation("validation.out_of_range", map[string]string{"field": "limit", "min": "1", "max": strconv.Itoa(maxPageSize)})
+		}
+	}
+
+	return cursor, limit, nil
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuditDB struct {
+	fakeDB
+	active bool
+}
+
+// InTx откатывает изменение active, если fn вернула ошибку.
+func (f *fakeAuditDB) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	active := f.active
+	if err := fn(ctx); err != nil {
+		f.active = active
+		return err
+	}
+	return nil
+}
+
+func (f *fakeAuditDB) CheckUser(_ context.Context, _ string) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeAuditDB) GetUser(_ context.Context, userID string) (models.UserActiveResponse, error) {
+	return models.UserActiveResponse{UserID: userID, IsActive: f.active}, nil
+}
+
+func (f *fakeAuditDB) UpdateActive(_ context.Context, _ string, isActive bool) (bool, error) {
+	f.active = isActive
+	return true, nil
+}
+
+func newAuditTestServer(t *testing.T) (*Server, *fakeAuditDB) {
+	t.Helper()
+
+	db := &fakeAuditDB{active: true}
+	return newTestServer(t, db), db
+}
+
+func TestAudit_RecordsAdminAction(t *testing.T) {
+	s, db := newAuditTestServer(t)
+
+	pair, err := s.jwtService.GenerateTokenPair("admin-1", "Admin", true, false)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/setIsActive", strings.NewReader(`{"user_id":"u2","is_active":false}`))
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	req.Header.Set(requestIDHeader, "req-audit-1")
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	require.Len(t, db.entries, 1)
+	entry := db.entries[0]
+	assert.Equal(t, "admin-1", entry.Actor)
+	assert.Equal(t, "users:setIsActive", entry.Action)
+	assert.Equal(t, "u2", entry.Target)
+	assert.Equal(t, "req-audit-1", entry.RequestID)
+	assert.JSONEq(t, `{"user_id":"u2","username":"","team_name":"","is_active":true}`, string(entry.Before))
+	assert.JSONEq(t, `{"user_id":"u2","username":"","team_name":"","is_active":false}`, string(entry.After))
+}
+
+func TestAudit_WriteFailureRollsBackChange(t *testing.T) {
+	s, db := newAuditTestServer(t)
+	db.writeErr = errors.New("audit_log unavailable")
+
+	pair, err := s.jwtService.GenerateTokenPair("admin-1", "Admin", true, false)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/setIsActive", strings.NewReader(`{"user_id":"u2","is_active":false}`))
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.True(t, db.active)
+	assert.Empty(t, db.entries)
+}
+
+func TestAudit_ListPaginates(t *testing.T) {
+	s, db := newAuditTestServer(t)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.WriteAuditEntry(context.Background(), models.AuditEntry{Actor: "admin-1", Action: "pullRequest:merge", Target: "pr-1"}))
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/audit?actor=admin-1&limit=2&from=2025-01-01T00:00:00Z", "", true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var page models.AuditPage
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, int64(3), page.Entries[0].ID)
+	require.NotNil(t, page.NextCursor)
+	assert.Equal(t, int64(2), *page.NextCursor)
+	assert.Equal(t, "admin-1", db.filter.Actor)
+	require.NotNil(t, db.filter.From)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/audit?limit=2&cursor=2", "", true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	page = models.AuditPage{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Entries, 1)
+	assert.Nil(t, page.NextCursor)
+
+	for _, target := range []string{"/audit?limit=0", "/audit?limit=1000", "/audit?cursor=abc", "/audit?from=yesterday"} {
+		rec = httptest.NewRecorder()
+		s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, target, "", true))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/audit", "", false))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuthDB struct {
+	fakeDB
+	teamsCreated int
+	metricsScope *string
+}
+
+func (f *fakeAuthDB) CreateTeam(_ context.Context, _ models.RequestTeamAddResponse) (bool, error) {
+	f.teamsCreated++
+	return true, nil
+}
+
+func (f *fakeAuthDB) GetTeamMetrics(_ context.Context, scopeUserID string) ([]models.TeamMetrics, int, int, error) {
+	f.metricsScope = &scopeUserID
+	return []models.TeamMetrics{}, 0, 0, nil
+}
+
+func (f *fakeAuthDB) CheckRoleUser(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeAuthDB) CheckOrgAdmin(_ context.Context, _ string) (bool, error) {
+	return true, nil
+}
+
+func newAuthTestServer(t *testing.T) (*Server, *fakeAuthDB) {
+	t.Helper()
+
+	db := &fakeAuthDB{}
+	return newTestServer(t, db), db
+}
+
+func authRequest(t *testing.T, s *Server, method, target, body string, orgAdmin bool) *http.Request {
+	t.Helper()
+
+	pair, err := s.jwtService.GenerateTokenPair("u1", "Alice", false, orgAdmin)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	return req
+}
+
+func TestTeamAdd_RequiresOrgAdmin(t *testing.T) {
+	s, db := newAuthTestServer(t)
+	body := `{"team_name":"backend","members":[{"user_id":"u1","username":"Alice","is_active":true}]}`
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/team/add", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/add", body, false))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, constants.FORBIDDEN, decodeErrorCode(t, rec))
+	assert.Zero(t, db.teamsCreated)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/add", body, true))
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, 1, db.teamsCreated)
+}
+
+func TestStatistic_ScopedToCaller(t *testing.T) {
+	s, db := newAuthTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/statistic", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Nil(t, db.metricsScope)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/statistic", "", false))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotNil(t, db.metricsScope)
+	assert.Equal(t, "u1", *db.metricsScope)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/statistic", "", true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "", *db.metricsScope)
+}
+
+func TestLogin_NeverGrantsOrgAdmin(t *testing.T) {
+	s, _ := newAuthTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"id":"u1","name":"Alice"}`)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var pair struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&pair))
+
+	claims, err := s.jwtService.ValidateToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, claims.OrgAdmin)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/team/add", strings.NewReader(`{"team_name":"backend","members":[]}`))
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	s.handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+func (s *Server) setCodeownersHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.CodeownersRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var saved models.Codeowners
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		saved, err = s.db.SetCodeowners(ctx, models.Codeowners{
+			TeamName:   req.TeamName,
+			Repository: req.Repository,
+			Content:    req.Content,
+		})
+		if err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: saved.TeamName + "/" + saved.Repository, after: saved}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"codeowners": saved,
+	})
+}
+
+func (s *Server) getCodeownersHandler(w http.ResponseWriter, r *http.Request) {
+	teamName, err := queryName(r, "team_name")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	repository, err := queryID(r, "repository")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	c, err := s.db.GetCodeowners(r.Context(), teamName, repository)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"codeowners": c,
+	})
+}
+
+func (s *Server) deleteCodeownersHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.CodeownersDeleteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var deleted models.Codeowners
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		if deleted, err = s.db.DeleteCodeowners(ctx, req.TeamName, req.Repository); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: deleted.TeamName + "/" + deleted.Repository, before: deleted}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"codeowners": deleted,
+	})
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCodeownersDB struct {
+	fakeDB
+	teams   map[string]bool
+	files   map[string]models.Codeowners
+	created models.PullRequestCreateRequest
+}
+
+func (f *fakeCodeownersDB) SetCodeowners(_ context.Context, c models.Codeowners) (models.Codeowners, error) {
+	if !f.teams[c.TeamName] {
+		return models.Codeowners{}, database.ErrNotFound
+	}
+	c.UpdatedAt = time.Now()
+	f.files[c.TeamName+"/"+c.Repository] = c
+	return c, nil
+}
+
+func (f *fakeCodeownersDB) GetCodeowners(_ context.Context, teamName, repository string) (models.Codeowners, error) {
+	c, ok := f.files[teamName+"/"+repository]
+	if !ok {
+		return models.Codeowners{}, database.ErrNotFound
+	}
+	return c, nil
+}
+
+func (f *fakeCodeownersDB) DeleteCodeowners(_ context.Context, teamName, repository string) (models.Codeowners, error) {
+	c, ok := f.files[teamName+"/"+repository]
+	if !ok {
+		return models.Codeowners{}, database.ErrNotFound
+	}
+	delete(f.files, teamName+"/"+repository)
+	return c, nil
+}
+
+func (f *fakeCodeownersDB) CreatePullRequestWithReviewers(_ context.Context, req models.PullRequestCreateRequest, _ models.ReviewerPolicy) (models.PullRequestResponse, error) {
+	f.created = req
+	return models.PullRequestResponse{PullRequestID: req.PullRequestId, AssignedReviewers: []string{}}, nil
+}
+
+func newCodeownersTestServer(t *testing.T) (*Server, *fakeCodeownersDB) {
+	t.Helper()
+
+	db := &fakeCodeownersDB{
+		teams: map[string]bool{"backend": true},
+		files: map[string]models.Codeowners{},
+	}
+	return newTestServer(t, db), db
+}
+
+func TestTeamCodeowners_Lifecycle(t *testing.T) {
+	s, db := newCodeownersTestServer(t)
+	body := `{"team_name":"backend","repository":"github:acme/api","content":"*.go @alice\n/docs/ @bob\n"}`
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/setCodeowners", body, false))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, db.files)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/setCodeowners", body, true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Len(t, db.entries, 1)
+	assert.Equal(t, "team:setCodeowners", db.entries[0].Action)
+	assert.Equal(t, "backend/github:acme/api", db.entries[0].Target)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/team/codeowners?team_name=backend&repository=github:acme/api", "", false))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Codeowners models.Codeowners `json:"codeowners"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "*.go @alice\n/docs/ @bob\n", resp.Codeowners.Content)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/deleteCodeowners", `{"team_name":"backend","repository":"github:acme/api"}`, true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, db.files)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/team/codeowners?team_name=backend&repository=github:acme/api", "", false))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, constants.NOT_FOUND, decodeErrorCode(t, rec))
+}
+
+func TestTeamSetCodeowners_Validation(t *testing.T) {
+	s, db := newCodeownersTestServer(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"invalid syntax", `{"team_name":"backend","repository":"billing","content":"*.go @alice\n!vendor/ @bob"}`, http.StatusBadRequest},
+		{"missing content", `{"team_name":"backend","repository":"billing"}`, http.StatusBadRequest},
+		{"bad repository", `{"team_name":"backend","repository":"billing service","content":"* @alice"}`, http.StatusBadRequest},
+		{"unknown team", `{"team_name":"frontend","repository":"billing","content":"* @alice"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/setCodeowners", tt.body, true))
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+	assert.Empty(t, db.files)
+	assert.Empty(t, db.entries)
+}
+
+func TestCreatePullRequest_ChangedFiles(t *testing.T) {
+	s, db := newCodeownersTestServer(t)
+
+	body := `{"pull_request_id":"github:acme/api#42","pull_request_name":"Tune pool","author_id":"u1",
+		"changed_files":["internal/database/postgresql.go","docs/setup.md"]}`
+	rec := httptest.NewRecorder()
+	s.createPullRequestHandler(rec, httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, []string{"internal/database/postgresql.go", "docs/setup.md"}, db.created.ChangedFiles)
+	assert.Equal(t, "github:acme/api", db.created.RepositoryName())
+
+	body = `{"pull_request_id":"pr-2","pull_request_name":"Tune pool","author_id":"u1","changed_files":[""]}`
+	rec = httptest.NewRecorder()
+	s.createPullRequestHandler(rec, httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, constants.VALIDATION_ERROR, decodeErrorCode(t, rec))
+}
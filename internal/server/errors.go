@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+var databaseErrors = map[error]*apierror.Error{
+	database.ErrNotFound:    apierror.ErrNotFound,
+	database.ErrPRExists:    apierror.ErrPRExists,
+	database.ErrPRMerged:    apierror.ErrPRMerged,
+	database.ErrPRClosed:    apierror.ErrPRClosed,
+	database.ErrNotAssigned: apierror.ErrNotAssigned,
+	database.ErrNoCandidate: apierror.ErrNoCandidate,
+
+	database.ErrNotServiceAccount: apierror.ErrNotServiceAccount,
+	database.ErrExternalIDTaken:   apierror.ErrExternalIDTaken,
+	database.ErrOIDCSubjectTaken:  apierror.ErrOIDCSubjectTaken,
+
+	database.ErrIdempotencyInProgress: apierror.ErrIdempotencyInProgress,
+}
+
+func toAPIError(err error) *apierror.Error {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for dbErr, mapped := range databaseErrors {
+		if errors.Is(err, dbErr) {
+			return mapped
+		}
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apierror.ErrPayloadTooLarge
+	case errors.Is(err, context.DeadlineExceeded):
+		return apierror.ErrRequestTimeout
+	}
+
+	return apierror.Internal(err)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := toAPIError(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		s.log.ErrorContext(r.Context(), "internal error", slog.Any("error", err))
+	}
+
+	lang := s.catalog.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", lang)
+
+	writeJSON(w, apiErr.Status, models.ErrorResponse{
+		Error: models.ErrorBody{
+			Code:    apiErr.Code,
+			Message: apiErr.Message(lang),
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to encode JSON response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const (
+	healthStatusOK          = "ok"
+	healthStatusFail        = "fail"
+	healthStatusUnavailable = "unavailable"
+
+	readinessCheckTimeout = 2 * time.Second
+)
+
+func (s *Server) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, models.HealthResponse{Status: healthStatusOK})
+}
+
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	checks := map[string]models.HealthCheck{
+		"shutdown": s.checkShutdown(),
+	}
+
+	dbCheck := s.runCheck(ctx, "database", s.db.Ping)
+	checks["database"] = dbCheck
+
+	if dbCheck.Status == healthStatusOK {
+		checks["migrations"] = s.runCheck(ctx, "migrations", s.db.CheckMigrations)
+	} else {
+		checks["migrations"] = models.HealthCheck{Status: healthStatusFail, Error: "database unavailable"}
+	}
+
+	resp := models.HealthResponse{Status: healthStatusOK, Checks: checks}
+	status := http.StatusOK
+	for _, check := range checks {
+		if check.Status != healthStatusOK {
+			resp.Status = healthStatusUnavailable
+			status = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	writeJSON(w, status, resp)
+}
+
+func (s *Server) checkShutdown() models.HealthCheck {
+	if s.shuttingDown.Load() {
+		return models.HealthCheck{Status: healthStatusFail, Error: "shutting down"}
+	}
+	return models.HealthCheck{Status: healthStatusOK}
+}
+
+func (s *Server) runCheck(ctx context.Context, name string, check func(ctx context.Context) error) models.HealthCheck {
+	err := check(ctx)
+	if err == nil {
+		return models.HealthCheck{Status: healthStatusOK}
+	}
+
+	s.log.WarnContext(ctx, "readiness check failed", slog.String("check", name), slog.Any("error", err))
+
+	switch {
+	case errors.Is(err, database.ErrNotConnected):
+		return models.HealthCheck{Status: healthStatusFail, Error: "not connected"}
+	case errors.Is(err, database.ErrMigrationsPending):
+		return models.HealthCheck{Status: healthStatusFail, Error: "migrations pending"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.HealthCheck{Status: healthStatusFail, Error: "timeout"}
+	default:
+		return models.HealthCheck{Status: healthStatusFail, Error: "unavailable"}
+	}
+}
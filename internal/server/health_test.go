@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHealthDB struct {
+	database.DB
+	pingErr      error
+	migrationErr error
+}
+
+func (f *fakeHealthDB) Ping(context.Context) error {
+	return f.pingErr
+}
+
+func (f *fakeHealthDB) CheckMigrations(context.Context) error {
+	return f.migrationErr
+}
+
+func newHealthTestServer(db database.DB) *Server {
+	s := newRouterTestServer()
+	s.db = db
+	s.setupRoutes()
+	return s
+}
+
+func serveHealth(t *testing.T, s *Server, path string) (int, models.HealthResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var resp models.HealthResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	return rec.Code, resp
+}
+
+func TestHealthz(t *testing.T) {
+	s := newHealthTestServer(&fakeHealthDB{pingErr: errors.New("down")})
+
+	code, resp := serveHealth(t, s, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", resp.Status)
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name         string
+		db           *fakeHealthDB
+		shuttingDown bool
+		wantCode     int
+		wantChecks   map[string]string
+	}{
+		{
+			name:       "ready",
+			db:         &fakeHealthDB{},
+			wantCode:   http.StatusOK,
+			wantChecks: map[string]string{"database": "ok", "migrations": "ok", "shutdown": "ok"},
+		},
+		{
+			name:       "database down",
+			db:         &fakeHealthDB{pingErr: fmt.Errorf("dial: %w", errors.New("connection refused"))},
+			wantCode:   http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"database": "fail", "migrations": "fail", "shutdown": "ok"},
+		},
+		{
+			name:       "migrations pending",
+			db:         &fakeHealthDB{migrationErr: fmt.Errorf("%w: idempotency_keys", database.ErrMigrationsPending)},
+			wantCode:   http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"database": "ok", "migrations": "fail", "shutdown": "ok"},
+		},
+		{
+			name:         "shutting down",
+			db:           &fakeHealthDB{},
+			shuttingDown: true,
+			wantCode:     http.StatusServiceUnavailable,
+			wantChecks:   map[string]string{"database": "ok", "migrations": "ok", "shutdown": "fail"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newHealthTestServer(tt.db)
+			s.shuttingDown.Store(tt.shuttingDown)
+
+			code, resp := serveHealth(t, s, "/readyz")
+			assert.Equal(t, tt.wantCode, code)
+
+			got := make(map[string]string, len(resp.Checks))
+			for name, check := range resp.Checks {
+				got[name] = check.Status
+				assert.NotContains(t, check.Error, "connection refused")
+			}
+			assert.Equal(t, tt.wantChecks, got)
+		})
+	}
+}
+
+func TestRunServer_DrainsBeforeShutdown(t *testing.T) {
+	cfg := &config.Config{JWT: testJWTConfig, Server: config.ServerConfig{DrainDelay: 300 * time.Millisecond, ShutdownTimeout: time.Second}}
+	s := New(cfg, &fakeHealthDB{}, discardLogger(), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.RunServer(ctx) }()
+	<-s.Ready()
+
+	code, _ := serveHealth(t, s, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		code, _ := serveHealth(t, s, "/readyz")
+		return code == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+
+	select {
+	case err := <-done:
+		t.Fatalf("server stopped before drain delay: %v", err)
+	default:
+	}
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop after drain delay")
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func (s *Server) idempotencyMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			s.writeError(w, r, apierror.Validation("validation.too_long", map[string]string{"field": idempotencyKeyHeader, "max": strconv.Itoa(maxIdempotencyKeyLength)}))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				s.writeError(w, r, apierror.ErrPayloadTooLarge)
+				return
+			}
+			s.writeError(w, r, apierror.Validation("validation.invalid_body", nil))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID, _ := r.Context().Value(userIDKey).(string)
+		fingerprint := sha256.Sum256(body)
+
+		record := models.IdempotencyRecord{
+			Key:         key,
+			Scope:       userID + " " + r.Method + " " + r.URL.Path,
+			Fingerprint: hex.EncodeToString(fingerprint[:]),
+			ExpiresAt:   time.Now().Add(s.idempotencyTTL),
+		}
+
+		existing, reserved, err := s.db.ReserveIdempotencyKey(r.Context(), record)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		if !reserved {
+			s.replayIdempotentResponse(w, r, record, existing)
+			return
+		}
+
+		ctx := context.WithoutCancel(r.Context())
+		rec := &responseRecorder{ResponseWriter: w}
+		completed := false
+		// ключ освобождается и при панике обработчика, иначе повтор получал бы IDEMPOTENCY_IN_PROGRESS до истечения TTL
+		defer func() {
+			if completed {
+				return
+			}
+			if err := s.db.ReleaseIdempotencyKey(ctx, record.Key, record.Scope); err != nil {
+				s.log.ErrorContext(ctx, "failed to release idempotency key", slog.Any("error", err))
+			}
+		}()
+
+		next(rec, r)
+
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		record.StatusCode = rec.status
+		record.ContentType = rec.Header().Get("Content-Type")
+		record.ResponseBody = rec.body.Bytes()
+		if err := s.db.CompleteIdempotencyKey(ctx, record); err != nil {
+			s.log.ErrorContext(ctx, "failed to store idempotent response", slog.Any("error", err))
+			return
+		}
+		completed = true
+	}
+}
+
+func (s *Server) replayIdempotentResponse(w http.ResponseWriter, r *http.Request, record, existing models.IdempotencyRecord) {
+	if existing.Fingerprint != record.Fingerprint {
+		s.writeError(w, r, apierror.New(constants.IDEMPOTENCY_KEY_REUSED, http.StatusUnprocessableEntity))
+		return
+	}
+
+	if existing.StatusCode == 0 {
+		s.writeError(w, r, apierror.ErrIdempotencyInProgress)
+		return
+	}
+
+	if existing.ContentType != "" {
+		w.Header().Set("Content-Type", existing.ContentType)
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(existing.StatusCode)
+	w.Write(existing.ResponseBody)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeIdempotencyDB struct {
+	database.DB
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func newFakeIdempotencyDB() *fakeIdempotencyDB {
+	return &fakeIdempotencyDB{records: map[string]models.IdempotencyRecord{}}
+}
+
+func (f *fakeIdempotencyDB) ReserveIdempotencyKey(_ context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if existing, ok := f.records[rec.Key+rec.Scope]; ok && existing.ExpiresAt.After(time.Now()) {
+		return existing, false, nil
+	}
+	f.records[rec.Key+rec.Scope] = rec
+	return rec, true, nil
+}
+
+func (f *fakeIdempotencyDB) CompleteIdempotencyKey(_ context.Context, rec models.IdempotencyRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records[rec.Key+rec.Scope] = rec
+	return nil
+}
+
+func (f *fakeIdempotencyDB) ReleaseIdempotencyKey(_ context.Context, key, scope string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.records, key+scope)
+	return nil
+}
+
+func newIdempotencyTestServer(status int) (*Server, *int) {
+	calls := 0
+	s := newRouterTestServer()
+	s.db, s.idempotencyTTL = newFakeIdempotencyDB(), time.Hour
+	handler := s.idempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `}`))
+	})
+	s.router.HandleFunc("POST /pullRequest/reassign", handler)
+	return s, &calls
+}
+
+func doIdempotentRequest(s *Server, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/reassign", strings.NewReader(body))
+	req.Header.Set(idempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddleware_ReplaysResponse(t *testing.T) {
+	s, calls := newIdempotencyTestServer(http.StatusOK)
+
+	first := doIdempotentRequest(s, "key-1", `{"pull_request_id":"pr-1"}`)
+	second := doIdempotentRequest(s, "key-1", `{"pull_request_id":"pr-1"}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(idempotentReplayedHeader))
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+}
+
+func TestIdempotencyMiddleware_RejectsDifferentBody(t *testing.T) {
+	s, calls := newIdempotencyTestServer(http.StatusOK)
+
+	doIdempotentRequest(s, "key-1", `{"pull_request_id":"pr-1"}`)
+	second := doIdempotentRequest(s, "key-1", `{"pull_request_id":"pr-2"}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, second.Code)
+	assert.Contains(t, second.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+}
+
+func TestIdempotencyMiddleware_ServerErrorIsNotStored(t *testing.T) {
+	s, calls := newIdempotencyTestServer(http.StatusInternalServerError)
+
+	doIdempotentRequest(s, "key-1", `{}`)
+	doIdempotentRequest(s, "key-1", `{}`)
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyMiddleware_WithoutKey(t *testing.T) {
+	s, calls := newIdempotencyTestServer(http.StatusOK)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/reassign", strings.NewReader(`{}`))
+		s.router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyMiddleware_PanicReleasesKey(t *testing.T) {
+	db := newFakeIdempotencyDB()
+	s := newRouterTestServer()
+	s.db, s.idempotencyTTL = db, time.Hour
+	s.router.HandleFunc("POST /pullRequest/reassign", s.idempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	assert.Panics(t, func() { doIdempotentRequest(s, "key-1", `{}`) })
+	assert.Empty(t, db.records)
+}
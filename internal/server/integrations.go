@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/integrations/github"
+	"github.com/Parnishkaspb/avito/internal/integrations/gitlab"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, apierror.ErrPayloadTooLarge
+		}
+		return nil, apierror.Validation("validation.invalid_body", nil)
+	}
+	return body, nil
+}
+
+func (s *Server) githubWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := github.VerifySignature(s.integration.GitHub.WebhookSecret, body, r.Header.Get(github.HeaderSignature)); err != nil {
+		s.writeError(w, r, apierror.ErrInvalidSignature)
+		return
+	}
+
+	switch r.Header.Get(github.HeaderEvent) {
+	case github.EventPing:
+		writeJSON(w, http.StatusOK, integrations.Result{Outcome: "pong"})
+		return
+	case github.EventPullRequest:
+	default:
+		writeJSON(w, http.StatusOK, integrations.Result{Outcome: integrations.OutcomeIgnored, Reason: "unsupported_event"})
+		return
+	}
+
+	deliveryID := r.Header.Get(github.HeaderDelivery)
+	if deliveryID == "" {
+		s.writeError(w, r, apierror.Validation("validation.required", map[string]string{"field": github.HeaderDelivery}))
+		return
+	}
+
+	ev, err := github.ParsePullRequestEvent(deliveryID, body)
+	if err != nil {
+		s.writeError(w, r, apierror.Validation("validation.invalid_body", nil))
+		return
+	}
+
+	s.ingest(w, r, ev)
+}
+
+func (s *Server) gitlabWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if err := gitlab.VerifyToken(s.integration.GitLab.WebhookToken, r.Header.Get(gitlab.HeaderToken)); err != nil {
+		s.writeError(w, r, apierror.ErrInvalidWebhookToken)
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if r.Header.Get(gitlab.HeaderEvent) != gitlab.EventMergeRequest {
+		writeJSON(w, http.StatusOK, integrations.Result{Outcome: integrations.OutcomeIgnored, Reason: "unsupported_event"})
+		return
+	}
+
+	deliveryID := gitlab.DeliveryID(r.Header)
+	if deliveryID == "" {
+		s.writeError(w, r, apierror.Validation("validation.required", map[string]string{"field": gitlab.HeaderEventUUID}))
+		return
+	}
+
+	ev, err := gitlab.ParseMergeRequestEvent(deliveryID, body)
+	if err != nil {
+		s.writeError(w, r, apierror.Validation("validation.invalid_body", nil))
+		return
+	}
+
+	s.ingest(w, r, ev)
+}
+
+// ingest применяет событие code host один раз на delivery id. Если применить не удалось
+// или автор ещё не сопоставлен, резерв снимается, чтобы повторная доставка сработала.
+func (s *Server) ingest(w http.ResponseWriter, r *http.Request, ev integrations.Event) {
+	ctx := r.Context()
+	log := s.log.With(
+		slog.String("provider", ev.Provider),
+		slog.String("delivery_id", ev.DeliveryID),
+		slog.String("pull_request_id", ev.PullRequestID),
+	)
+
+	fresh, err := s.db.ReserveIntegrationDelivery(ctx, ev.Provider, ev.DeliveryID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !fresh {
+		writeJSON(w, http.StatusOK, integrations.Result{Outcome: "duplicate"})
+		return
+	}
+
+	processed := false
+	// резерв снимается и при панике в Apply, иначе повтор доставки считался бы дубликатом
+	defer func() {
+		if processed {
+			return
+		}
+		releaseCtx := context.WithoutCancel(ctx)
+		if err := s.db.ReleaseIntegrationDelivery(releaseCtx, ev.Provider, ev.DeliveryID); err != nil {
+			log.ErrorContext(releaseCtx, "failed to release integration delivery", slog.Any("error", err))
+		}
+	}()
+
+	// изменения PR от code host попадают в журнал аудита так же, как изменения через API
+	entry := models.AuditEntry{
+		Actor:     "integration:" + ev.Provider,
+		Action:    routeScope(r),
+		RequestID: getRequestID(ctx),
+	}
+	var res integrations.Result
+	err = s.auditedAs(ctx, entry, func(ctx context.Context) (auditChange, error) {
+		before := s.pullRequestSnapshot(ctx, ev.PullRequestID)
+
+		var err error
+		if res, err = s.integrations.Apply(ctx, ev, s.currentSettings().reviewers); err != nil {
+			return auditChange{}, err
+		}
+
+		switch res.Outcome {
+		case integrations.OutcomeCreated, integrations.OutcomeMerged, integrations.OutcomeClosed, integrations.OutcomeReopened:
+			return auditChange{target: ev.PullRequestID, before: before, after: s.pullRequestSnapshot(ctx, ev.PullRequestID)}, nil
+		}
+		return auditChange{}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	processed = res.Reason != "unknown_author"
+
+	switch res.Outcome {
+	case integrations.OutcomeCreated:
+		s.metrics.PullRequestCreated(len(res.PullRequest.AssignedReviewers))
+	case integrations.OutcomeMerged:
+		s.metrics.PullRequestMerged()
+	}
+	log.InfoContext(ctx, "integration event processed",
+		slog.String("action", ev.Action), slog.String("outcome", res.Outcome), slog.String("reason", res.Reason))
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) setIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.IdentityMappingRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var m models.IdentityMapping
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		m, err = s.db.SetIdentityMapping(ctx, models.IdentityMapping{
+			Provider:   req.Provider,
+			Login:      req.Login,
+			ExternalID: req.ExternalID,
+			UserID:     req.UserID,
+		})
+		if err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: m.Provider + ":" + m.Login, after: m}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"identity": m,
+	})
+}
+
+func (s *Server) listIdentitiesHandler(w http.ResponseWriter, r *http.Request) {
+	mappings, err := s.db.ListIdentityMappings(r.Context(), r.URL.Query().Get("provider"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"identities": mappings,
+	})
+}
+
+func (s *Server) deleteIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.IdentityMappingDeleteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var m models.IdentityMapping
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		if m, err = s.db.DeleteIdentityMapping(ctx, req.Provider, req.Login); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: m.Provider + ":" + m.Login, before: m}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"identity": m,
+	})
+}
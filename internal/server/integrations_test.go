@@ -0,0 +1,311 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/integrations"
+	"github.com/Parnishkaspb/avito/internal/integrations/github"
+	"github.com/Parnishkaspb/avito/internal/integrations/gitlab"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testGitHubSecret = "gh-webhook-secret"
+	testGitLabToken  = "gl-webhook-token"
+)
+
+type fakeIntegrationDB struct {
+	fakeDB
+	identities   map[string]string
+	deliveries   map[string]bool
+	status       map[string]string
+	createPanics bool
+}
+
+func (f *fakeIntegrationDB) ResolveIdentity(_ context.Context, provider, login, externalID string) (string, error) {
+	userID, ok := f.identities[provider+"/"+login]
+	if !ok {
+		userID, ok = f.identities[provider+"/#"+externalID]
+	}
+	if !ok {
+		return "", database.ErrNotFound
+	}
+	return userID, nil
+}
+
+func (f *fakeIntegrationDB) ReserveIntegrationDelivery(_ context.Context, provider, deliveryID string) (bool, error) {
+	key := provider + "/" + deliveryID
+	if f.deliveries[key] {
+		return false, nil
+	}
+	f.deliveries[key] = true
+	return true, nil
+}
+
+func (f *fakeIntegrationDB) ReleaseIntegrationDelivery(_ context.Context, provider, deliveryID string) error {
+	delete(f.deliveries, provider+"/"+deliveryID)
+	return nil
+}
+
+func (f *fakeIntegrationDB) CreatePullRequestWithReviewers(_ context.Context, req models.PullRequestCreateRequest, _ models.ReviewerPolicy) (models.PullRequestResponse, error) {
+	if f.createPanics {
+		panic("create failed")
+	}
+	if _, ok := f.status[req.PullRequestId]; ok {
+		return models.PullRequestResponse{}, database.ErrPRExists
+	}
+	f.status[req.PullRequestId] = "OPEN"
+	return models.PullRequestResponse{PullRequestID: req.PullRequestId, AuthorID: req.AuthorID, Status: "OPEN", AssignedReviewers: []string{"u2"}}, nil
+}
+
+func (f *fakeIntegrationDB) MergePullRequest(_ context.Context, prID string) (models.PullRequest, bool, error) {
+	if _, ok := f.status[prID]; !ok {
+		return models.PullRequest{}, false, database.ErrNotFound
+	}
+	merged := f.status[prID] == "OPEN"
+	f.status[prID] = "MERGED"
+	return models.PullRequest{PullRequestID: prID, Status: "MERGED"}, merged, nil
+}
+
+func (f *fakeIntegrationDB) ClosePullRequest(_ context.Context, prID string) (bool, error) {
+	if _, ok := f.status[prID]; !ok {
+		return false, database.ErrNotFound
+	}
+	closed := f.status[prID] == "OPEN"
+	f.status[prID] = "CLOSED"
+	return closed, nil
+}
+
+func (f *fakeIntegrationDB) ReopenPullRequest(_ context.Context, prID string) (bool, error) {
+	if _, ok := f.status[prID]; !ok {
+		return false, database.ErrNotFound
+	}
+	reopened := f.status[prID] == "CLOSED"
+	f.status[prID] = "OPEN"
+	return reopened, nil
+}
+
+func newIntegrationTestServer(t *testing.T) (*Server, *fakeIntegrationDB) {
+	t.Helper()
+
+	db := &fakeIntegrationDB{
+		identities: map[string]string{"github/octocat": "u1", "gitlab/jdoe": "u1"},
+		deliveries: map[string]bool{},
+		status:     map[string]string{},
+	}
+	s := newTestServer(t, db, func(cfg *config.Config) {
+		cfg.Integration.GitHub = config.GitHubConfig{Enabled: true, WebhookSecret: testGitHubSecret}
+		cfg.Integration.GitLab = config.GitLabConfig{Enabled: true, WebhookToken: testGitLabToken}
+	})
+	return s, db
+}
+
+func githubRequest(t *testing.T, event, deliveryID, fixture string) *http.Request {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("..", "integrations", "github", "testdata", fixture))
+	require.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte(testGitHubSecret))
+	mac.Write(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/integrations/github/webhook", bytes.NewReader(body))
+	req.Header.Set(github.HeaderEvent, event)
+	req.Header.Set(github.HeaderDelivery, deliveryID)
+	req.Header.Set(github.HeaderSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func gitlabRequest(t *testing.T, deliveryID, fixture string) *http.Request {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("..", "integrations", "gitlab", "testdata", fixture))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/integrations/gitlab/webhook", bytes.NewReader(body))
+	req.Header.Set(gitlab.HeaderEvent, gitlab.EventMergeRequest)
+	req.Header.Set(gitlab.HeaderEventUUID, deliveryID)
+	req.Header.Set(gitlab.HeaderToken, testGitLabToken)
+	return req
+}
+
+func replay(t *testing.T, s *Server, req *http.Request) integrations.Result {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res integrations.Result
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	return res
+}
+
+func TestGitHubWebhook_ReplaysLifecycle(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+	const prID = "github:avito-tech/review-service#42"
+
+	steps := []struct {
+		fixture string
+		outcome string
+		status  string
+	}{
+		{"pull_request_opened.json", integrations.OutcomeCreated, "OPEN"},
+		{"pull_request_closed.json", integrations.OutcomeClosed, "CLOSED"},
+		{"pull_request_reopened.json", integrations.OutcomeReopened, "OPEN"},
+		{"pull_request_labeled.json", integrations.OutcomeIgnored, "OPEN"},
+		{"pull_request_merged.json", integrations.OutcomeMerged, "MERGED"},
+	}
+
+	for i, step := range steps {
+		res := replay(t, s, githubRequest(t, github.EventPullRequest, "delivery-"+step.fixture, step.fixture))
+		assert.Equal(t, step.outcome, res.Outcome, "step %d", i)
+		assert.Equal(t, step.status, db.status[prID], "step %d", i)
+	}
+
+	res := replay(t, s, githubRequest(t, github.EventPullRequest, "delivery-pull_request_opened.json", "pull_request_opened.json"))
+	assert.Equal(t, "duplicate", res.Outcome)
+
+	// каждое изменение PR (кроме проигнорированного события) записано в аудит
+	require.Len(t, db.entries, 4)
+	for _, entry := range db.entries {
+		assert.Equal(t, "integration:github", entry.Actor)
+		assert.Equal(t, "integrations:github:webhook", entry.Action)
+		assert.Equal(t, prID, entry.Target)
+	}
+}
+
+func TestGitHubWebhook_DraftThenReady(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+	const prID = "github:avito-tech/review-service#43"
+
+	res := replay(t, s, githubRequest(t, github.EventPullRequest, "d1", "pull_request_opened_draft.json"))
+	assert.Equal(t, integrations.Result{Outcome: integrations.OutcomeIgnored, Reason: "draft"}, res)
+	assert.Empty(t, db.status)
+
+	res = replay(t, s, githubRequest(t, github.EventPullRequest, "d2", "pull_request_ready_for_review.json"))
+	assert.Equal(t, integrations.OutcomeCreated, res.Outcome)
+	assert.Equal(t, "OPEN", db.status[prID])
+}
+
+func TestGitHubWebhook_UnknownAuthorCanBeRedelivered(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+	delete(db.identities, "github/octocat")
+
+	res := replay(t, s, githubRequest(t, github.EventPullRequest, "d1", "pull_request_opened.json"))
+	assert.Equal(t, "unknown_author", res.Reason)
+
+	db.identities["github/octocat"] = "u1"
+	res = replay(t, s, githubRequest(t, github.EventPullRequest, "d1", "pull_request_opened.json"))
+	assert.Equal(t, integrations.OutcomeCreated, res.Outcome)
+}
+
+func TestGitHubWebhook_PanicReleasesDelivery(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+	db.createPanics = true
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, githubRequest(t, github.EventPullRequest, "d1", "pull_request_opened.json"))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, db.deliveries)
+
+	db.createPanics = false
+	res := replay(t, s, githubRequest(t, github.EventPullRequest, "d1", "pull_request_opened.json"))
+	assert.Equal(t, integrations.OutcomeCreated, res.Outcome)
+}
+
+func TestGitHubWebhook_RejectsBadSignature(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+
+	req := githubRequest(t, github.EventPullRequest, "d1", "pull_request_opened.json")
+	req.Header.Set(github.HeaderSignature, "sha256=00")
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, constants.UNAUTHORIZED, decodeErrorCode(t, rec))
+	assert.Empty(t, db.deliveries)
+}
+
+func TestGitHubWebhook_Ping(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+
+	res := replay(t, s, githubRequest(t, github.EventPing, "d1", "ping.json"))
+	assert.Equal(t, "pong", res.Outcome)
+	assert.Empty(t, db.deliveries)
+}
+
+func TestGitLabWebhook_ReplaysLifecycle(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+	const prID = "gitlab:15!7"
+
+	steps := []struct {
+		fixture string
+		outcome string
+		status  string
+	}{
+		{"merge_request_open_draft.json", integrations.OutcomeIgnored, ""},
+		{"merge_request_update_ready.json", integrations.OutcomeCreated, "OPEN"},
+		{"merge_request_update_draft.json", integrations.OutcomeIgnored, "OPEN"},
+		{"merge_request_update_labels.json", integrations.OutcomeIgnored, "OPEN"},
+		{"merge_request_close.json", integrations.OutcomeClosed, "CLOSED"},
+		{"merge_request_reopen.json", integrations.OutcomeReopened, "OPEN"},
+		{"merge_request_merge.json", integrations.OutcomeMerged, "MERGED"},
+	}
+
+	for i, step := range steps {
+		res := replay(t, s, gitlabRequest(t, "delivery-"+step.fixture, step.fixture))
+		assert.Equal(t, step.outcome, res.Outcome, "step %d", i)
+		assert.Equal(t, step.status, db.status[prID], "step %d", i)
+	}
+
+	res := replay(t, s, gitlabRequest(t, "delivery-merge_request_merge.json", "merge_request_merge.json"))
+	assert.Equal(t, "duplicate", res.Outcome)
+}
+
+func TestGitLabWebhook_SharesIdentitiesAndNamespacesIDs(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+
+	res := replay(t, s, githubRequest(t, github.EventPullRequest, "same-id", "pull_request_opened.json"))
+	assert.Equal(t, integrations.OutcomeCreated, res.Outcome)
+
+	req := gitlabRequest(t, "ignored-uuid", "merge_request_open.json")
+	req.Header.Set(gitlab.HeaderIdempotencyKey, "same-id")
+	res = replay(t, s, req)
+	require.Equal(t, integrations.OutcomeCreated, res.Outcome)
+	assert.Equal(t, "u1", res.PullRequest.AuthorID)
+
+	assert.Contains(t, db.status, "github:avito-tech/review-service#42")
+	assert.Contains(t, db.status, "gitlab:15!7")
+	assert.True(t, db.deliveries["gitlab/same-id"])
+}
+
+func TestGitLabWebhook_RejectsBadToken(t *testing.T) {
+	s, db := newIntegrationTestServer(t)
+
+	for _, token := range []string{"", "wrong"} {
+		req := gitlabRequest(t, "d1", "merge_request_open.json")
+		req.Header.Set(gitlab.HeaderToken, token)
+
+		rec := httptest.NewRecorder()
+		s.handler().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, constants.UNAUTHORIZED, decodeErrorCode(t, rec))
+	}
+	assert.Empty(t, db.deliveries)
+}
@@ -13,5 +13,5 @@ type IServer interface {
 	setupRoutes()
 	RunServer(ctx context.Context) error
 	gracefulShutdown(server *http.Server) error
-	writeError(w http.ResponseWriter, code, msg string, status int)
+	writeError(w http.ResponseWriter, r *http.Request, err error)
 }
@@ -0,0 +1,341 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/logger"
+	"github.com/Parnishkaspb/avito/internal/ratelimit"
+	"github.com/Parnishkaspb/avito/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader       = "X-Request-ID"
+	maxRequestIDLength    = 128
+	requestIDKey          = contextKey("requestID")
+	requestInfoKey        = contextKey("requestInfo")
+	unmatchedRoutePattern = "unmatched"
+)
+
+type Middleware func(http.Handler) http.Handler
+
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+type requestInfo struct {
+	UserID string
+	Route  string
+}
+
+func getRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func setRequestRoute(ctx context.Context, route string) {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		info.Route = route
+	}
+}
+
+func setRequestUser(ctx context.Context, userID string) {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		info.UserID = userID
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+func (rec *statusRecorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (s *Server) handler() http.Handler {
+	return Chain(s.router,
+		s.requestIDMiddleware,
+		s.tracingMiddleware,
+		s.metricsMiddleware,
+		s.accessLogMiddleware,
+		s.recoverMiddleware,
+		s.limitsMiddleware,
+	)
+}
+
+func (s *Server) handle(method, path string, handler http.HandlerFunc) {
+	s.router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		setRequestRoute(r.Context(), r.Pattern)
+
+		if r.Method != method && !(method == http.MethodGet && r.Method == http.MethodHead) {
+			w.Header().Set("Allow", method)
+			s.writeError(w, r, apierror.New(constants.METHOD_NOT_ALLOWED, http.StatusMethodNotAllowed))
+			return
+		}
+
+		handler(w, r)
+	})
+}
+
+func (s *Server) notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeError(w, r, apierror.ErrNotFound)
+}
+
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx = context.WithValue(ctx, requestInfoKey, &requestInfo{})
+		ctx = logger.WithAttrs(ctx, slog.String("request_id", requestID))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *Server) accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		s.log.InfoContext(r.Context(), "http request",
+			slog.String("method", r.Method),
+			slog.String("route", routePattern(r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.statusCode()),
+			slog.Int("bytes", rec.bytes),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("user_id", getRequestInfo(r.Context()).UserID),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(tracing.InstrumentationName).Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("http.request_id", getRequestID(r.Context())),
+			),
+		)
+		defer span.End()
+
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			ctx = logger.WithAttrs(ctx, slog.String("trace_id", spanContext.TraceID().String()))
+		}
+
+		rec := &statusRecorder{ResponseWriter: w}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rec, r)
+
+		route := routePattern(r)
+		status := rec.statusCode()
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		s.metrics.ObserveHTTPRequest(r.Method, routePattern(r), rec.statusCode(), time.Since(start))
+	})
+}
+
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.metrics == nil {
+		s.writeError(w, r, apierror.ErrNotFound)
+		return
+	}
+
+	s.metrics.Handler().ServeHTTP(w, r)
+}
+
+func (s *Server) limitsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.httpConfig.MaxBodyBytes > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, s.httpConfig.MaxBodyBytes)
+		}
+
+		if s.httpConfig.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), s.httpConfig.Timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(p)
+			}
+
+			s.log.ErrorContext(r.Context(), "panic in handler",
+				slog.Any("panic", p),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			s.writeError(w, r, apierror.Internal(fmt.Errorf("panic: %v", p)))
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func getRequestInfo(ctx context.Context) requestInfo {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		return *info
+	}
+	return requestInfo{}
+}
+
+func routePattern(r *http.Request) string {
+	route := getRequestInfo(r.Context()).Route
+	if route == "" {
+		route = r.Pattern
+	}
+
+	if route == "" || route == "/" {
+		return unmatchedRoutePattern
+	}
+	return route
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// ipRateLimitMiddleware ограничивает общий поток запросов с одного IP и стоит до authMiddleware,
+// чтобы перебор JWT и API-ключей тоже упирался в лимит.
+func (s *Server) ipRateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.limiter == nil {
+			next(w, r)
+			return
+		}
+
+		if allowed, retryAfter := s.limiter.AllowIP(s.limiter.ClientIP(r)); !allowed {
+			s.metrics.RateLimited(routePattern(r))
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(retryAfter)))
+			s.writeError(w, r, apierror.ErrRateLimited)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) rateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.limiter == nil {
+			next(w, r)
+			return
+		}
+
+		key := "ip:" + s.limiter.ClientIP(r)
+		if userID, ok := r.Context().Value(userIDKey).(string); ok && userID != "" {
+			key = "user:" + userID
+		}
+
+		route := routePattern(r)
+		if allowed, retryAfter := s.limiter.Allow(route, key); !allowed {
+			s.metrics.RateLimited(route)
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(retryAfter)))
+			s.writeError(w, r, apierror.ErrRateLimited)
+			return
+		}
+
+		next(w, r)
+	}
+}
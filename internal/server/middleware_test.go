@@ -0,0 +1,268 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/logger"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/Parnishkaspb/avito/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newMiddlewareTestServer() *Server {
+	s := newRouterTestServer()
+	s.handle(http.MethodPost, "/ok", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"request_id": getRequestID(r.Context())})
+	})
+	s.handle(http.MethodGet, "/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	s.router.HandleFunc("/", s.notFoundHandler)
+	return s
+}
+
+func decodeErrorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var resp models.ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	return resp.Error.Code
+}
+
+func TestMiddleware_RequestIDPropagation(t *testing.T) {
+	s := newMiddlewareTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/ok", nil)
+	req.Header.Set(requestIDHeader, "client-id-1")
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, req)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "client-id-1", rec.Header().Get(requestIDHeader))
+	assert.Equal(t, "client-id-1", body["request_id"])
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/ok", nil))
+	assert.Len(t, rec.Header().Get(requestIDHeader), 32)
+}
+
+func TestMiddleware_RecoverFromPanic(t *testing.T) {
+	s := newMiddlewareTestServer()
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(requestIDHeader))
+	assert.Equal(t, constants.INTERNAL_ERROR, decodeErrorCode(t, rec))
+}
+
+func TestMiddleware_MethodNotAllowed(t *testing.T) {
+	s := newMiddlewareTestServer()
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+	assert.Equal(t, constants.METHOD_NOT_ALLOWED, decodeErrorCode(t, rec))
+}
+
+func TestMiddleware_UnknownRoute(t *testing.T) {
+	s := newMiddlewareTestServer()
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, constants.NOT_FOUND, decodeErrorCode(t, rec))
+}
+
+func TestMiddleware_AccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := logger.New(config.LogConfig{Level: "info", Format: logger.FormatJSON}, &buf)
+	require.NoError(t, err)
+
+	s := newMiddlewareTestServer()
+	s.log = log
+	s.handle(http.MethodGet, "/whoami", func(w http.ResponseWriter, r *http.Request) {
+		setRequestUser(r.Context(), "u1")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set(requestIDHeader, "req-42")
+	s.handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "http request", entry["msg"])
+	assert.Equal(t, "req-42", entry["request_id"])
+	assert.Equal(t, "/whoami", entry["route"])
+	assert.Equal(t, float64(http.StatusNoContent), entry["status"])
+	assert.Equal(t, "u1", entry["user_id"])
+}
+
+func TestMiddleware_TracingPropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	s := newMiddlewareTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/ok", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	s.handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST /ok", spans[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func newLimitsTestServer(cfg config.ServerConfig) *Server {
+	s := newRouterTestServer()
+	s.httpConfig = cfg
+	return s
+}
+
+func TestMiddleware_BodyTooLarge(t *testing.T) {
+	s := newLimitsTestServer(config.ServerConfig{MaxBodyBytes: 16})
+	s.handle(http.MethodPost, "/limited", func(w http.ResponseWriter, r *http.Request) {
+		var req models.LoginRequest
+		if err := decodeJSON(r, &req); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	body := `{"id":"u1","name":"` + strings.Repeat("a", 64) + `"}`
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/limited", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, constants.PAYLOAD_TOO_LARGE, decodeErrorCode(t, rec))
+}
+
+func TestMiddleware_BodyTooLargeWithIdempotencyKey(t *testing.T) {
+	s := newLimitsTestServer(config.ServerConfig{MaxBodyBytes: 16})
+	s.db = newFakeIdempotencyDB()
+	s.handle(http.MethodPost, "/limited", s.idempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not be called")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/limited", strings.NewReader(strings.Repeat("x", 64)))
+	req.Header.Set(idempotencyKeyHeader, "key-1")
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestMiddleware_RequestTimeout(t *testing.T) {
+	s := newLimitsTestServer(config.ServerConfig{Timeout: 20 * time.Millisecond})
+	s.handle(http.MethodPost, "/limited", func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), deadline, 20*time.Millisecond)
+
+		<-r.Context().Done()
+		s.writeError(w, r, fmt.Errorf("ошибка запроса: %w", r.Context().Err()))
+	})
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/limited", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, constants.REQUEST_TIMEOUT, decodeErrorCode(t, rec))
+}
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	s := newRouterTestServer()
+	s.limiter = ratelimit.New(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{RPS: 100, Burst: 100},
+		Routes:  map[string]config.RateLimitRule{"/limited": {RPS: 0.5, Burst: 1}},
+	})
+	s.handle(http.MethodPost, "/limited", s.rateLimitMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(remoteAddr, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/limited", nil)
+		req.RemoteAddr = remoteAddr
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), userIDKey, userID))
+		}
+		rec := httptest.NewRecorder()
+		s.handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve("203.0.113.1:1000", "").Code)
+
+	rec := serve("203.0.113.1:1001", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, constants.RATE_LIMITED, decodeErrorCode(t, rec))
+
+	assert.Equal(t, http.StatusNoContent, serve("203.0.113.2:1000", "").Code, "other IPs are not affected")
+
+	assert.Equal(t, http.StatusNoContent, serve("203.0.113.1:1000", "u1").Code, "authenticated users are keyed by user ID")
+	assert.Equal(t, http.StatusTooManyRequests, serve("203.0.113.9:1000", "u1").Code)
+	assert.Equal(t, http.StatusNoContent, serve("203.0.113.9:1000", "u2").Code)
+}
+
+func TestMiddleware_IPRateLimitBeforeAuth(t *testing.T) {
+	s := newRouterTestServer()
+	s.jwtService = NewTokenService(testJWTConfig)
+	s.limiter = ratelimit.New(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{RPS: 100, Burst: 100},
+		PerIP:   config.RateLimitRule{RPS: 0.5, Burst: 2},
+	})
+	s.handle(http.MethodGet, "/limited", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))))
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.RemoteAddr = "203.0.113.1:1000"
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		s.handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// невалидные токены расходуют лимит IP
+	assert.Equal(t, http.StatusUnauthorized, serve("bad-1").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("bad-2").Code)
+
+	rec := serve("bad-3")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, constants.RATE_LIMITED, decodeErrorCode(t, rec))
+}
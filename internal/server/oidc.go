@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/Parnishkaspb/avito/internal/oidc"
+)
+
+func (s *Server) oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	authURL, err := s.oidc.AuthCodeURL(r.Context())
+	if err != nil {
+		if errors.Is(err, oidc.ErrTooManyLogins) {
+			s.writeError(w, r, apierror.ErrRateLimited)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (s *Server) oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if idpErr := query.Get("error"); idpErr != "" {
+		s.log.WarnContext(r.Context(), "oidc provider returned error",
+			slog.String("error", idpErr), slog.String("description", query.Get("error_description")))
+		s.writeError(w, r, apierror.ErrSSOFailed)
+		return
+	}
+
+	state, code := query.Get("state"), query.Get("code")
+	if state == "" || code == "" {
+		s.writeError(w, r, apierror.ErrSSOState)
+		return
+	}
+
+	identity, err := s.oidc.Exchange(r.Context(), state, code)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidState) {
+			s.writeError(w, r, apierror.ErrSSOState)
+			return
+		}
+		s.log.WarnContext(r.Context(), "oidc exchange failed", slog.Any("error", err))
+		s.writeError(w, r, apierror.ErrSSOFailed)
+		return
+	}
+
+	user, err := s.db.ResolveOIDCUser(r.Context(), identity, s.oidcConfig.UserClaim, s.oidcConfig.AutoProvision)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		s.writeError(w, r, apierror.ErrSSOUnknownUser)
+		return
+	case errors.Is(err, database.ErrUserInactive) || (err == nil && !user.IsActive):
+		s.log.WarnContext(r.Context(), "oidc login of inactive user rejected", slog.String("user_id", user.ID))
+		s.writeError(w, r, apierror.ErrSSOInactive)
+		return
+	case err != nil:
+		s.writeError(w, r, err)
+		return
+	}
+
+	tokenPair, err := s.issueTokens(r.Context(), user.ID, user.Username, true)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "oidc login", slog.String("user_id", user.ID))
+
+	writeJSON(w, http.StatusOK, tokenPair)
+}
+
+func (s *Server) setUserIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.UserIdentityRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var identity models.UserIdentity
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		before, err := s.db.GetUserIdentity(ctx, req.UserID)
+		if err != nil {
+			return auditChange{}, err
+		}
+
+		if identity, err = s.db.SetUserIdentity(ctx, req); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: req.UserID, before: before, after: identity}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]models.UserIdentity{
+		"identity": identity,
+	})
+}
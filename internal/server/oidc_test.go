@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/jwt"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/Parnishkaspb/avito/internal/oidc/fakeidp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOIDCDB struct {
+	fakeDB
+	users         map[string]models.User
+	accounts      map[string]models.User
+	autoProvision bool
+	identity      models.OIDCIdentity
+}
+
+func (f *fakeOIDCDB) ResolveOIDCUser(_ context.Context, identity models.OIDCIdentity, matchBy string, autoProvision bool) (models.User, error) {
+	f.identity = identity
+	f.autoProvision = autoProvision
+
+	key := identity.Subject
+	if matchBy == "email" {
+		key = identity.Email
+	}
+	user, ok := f.users[key]
+	if !ok {
+		return models.User{}, database.ErrNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeOIDCDB) GetUserIdentity(_ context.Context, userID string) (models.UserIdentity, error) {
+	if _, ok := f.accounts[userID]; !ok {
+		return models.UserIdentity{}, database.ErrNotFound
+	}
+	identity := models.UserIdentity{UserID: userID}
+	for subject, user := range f.users {
+		if user.ID == userID {
+			identity.OIDCSubject = subject
+		}
+	}
+	return identity, nil
+}
+
+func (f *fakeOIDCDB) SetUserIdentity(ctx context.Context, req models.UserIdentityRequest) (models.UserIdentity, error) {
+	identity, err := f.GetUserIdentity(ctx, req.UserID)
+	if err != nil {
+		return models.UserIdentity{}, err
+	}
+	if req.OIDCSubject != nil {
+		if owner, ok := f.users[*req.OIDCSubject]; ok && owner.ID != req.UserID {
+			return models.UserIdentity{}, database.ErrOIDCSubjectTaken
+		}
+		delete(f.users, identity.OIDCSubject)
+		f.users[*req.OIDCSubject] = f.accounts[req.UserID]
+		identity.OIDCSubject = *req.OIDCSubject
+	}
+	return identity, nil
+}
+
+func (f *fakeOIDCDB) CheckRoleUser(_ context.Context, _ string) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeOIDCDB) CheckOrgAdmin(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func newOIDCTestServer(t *testing.T) (*Server, *fakeOIDCDB, *fakeidp.Server) {
+	t.Helper()
+
+	idp := fakeidp.New(t, "avito")
+	db := &fakeOIDCDB{users: map[string]models.User{
+		"alice-sub": {ID: "u1", Username: "Alice", IsActive: true},
+		"bob-sub":   {ID: "u2", Username: "Bob", IsActive: false},
+	}, accounts: map[string]models.User{
+		"u1": {ID: "u1", Username: "Alice", IsActive: true},
+		"u2": {ID: "u2", Username: "Bob", IsActive: false},
+		"u3": {ID: "u3", Username: "Carol", IsActive: true},
+	}}
+	s := newTestServer(t, db, func(cfg *config.Config) {
+		cfg.OIDC = config.OIDCConfig{
+			Enabled:       true,
+			Issuer:        idp.URL,
+			ClientID:      "avito",
+			RedirectURL:   "http://localhost:8080/auth/oidc/callback",
+			Scopes:        []string{"openid"},
+			UserClaim:     "sub",
+			StateTTL:      time.Minute,
+			AutoProvision: true,
+			GroupsClaim:   "groups",
+			TeamPrefix:    "team:",
+		}
+	})
+	return s, db, idp
+}
+
+func oidcCallback(t *testing.T, s *Server, idp *fakeidp.Server, claims map[string]any) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+
+	redirect, err := idp.Authorize(rec.Header().Get("Location"), claims)
+	require.NoError(t, err)
+
+	target := "/auth/oidc/callback?" + redirect.RawQuery
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec, target
+}
+
+func TestOIDC_LoginIssuesTokenPair(t *testing.T) {
+	s, db, idp := newOIDCTestServer(t)
+
+	rec, target := oidcCallback(t, s, idp, map[string]any{"sub": "alice-sub", "groups": []string{"team:backend"}})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var pair jwt.TokenPair
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&pair))
+
+	claims, err := s.jwtService.ValidateToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "u1", claims.UserID)
+	assert.Equal(t, "Alice", claims.Name)
+	assert.True(t, claims.Role)
+	assert.True(t, db.autoProvision)
+	assert.Equal(t, []string{"backend"}, db.identity.Teams)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestOIDC_UnknownUser(t *testing.T) {
+	s, _, idp := newOIDCTestServer(t)
+
+	rec, _ := oidcCallback(t, s, idp, map[string]any{"sub": "mallory-sub"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, constants.FORBIDDEN, decodeErrorCode(t, rec))
+}
+
+func TestOIDC_InactiveUser(t *testing.T) {
+	s, _, idp := newOIDCTestServer(t)
+
+	rec, _ := oidcCallback(t, s, idp, map[string]any{"sub": "bob-sub"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, constants.FORBIDDEN, decodeErrorCode(t, rec))
+	assert.NotContains(t, rec.Body.String(), "access_token")
+}
+
+func TestOIDC_ProviderError(t *testing.T) {
+	s, _, _ := newOIDCTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?error=access_denied", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, constants.UNAUTHORIZED, decodeErrorCode(t, rec))
+}
+
+func TestOIDC_SetIdentityLinksExistingUser(t *testing.T) {
+	s, db, idp := newOIDCTestServer(t)
+	s.oidcConfig.AutoProvision = false
+
+	rec, _ := oidcCallback(t, s, idp, map[string]any{"sub": "carol-sub"})
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	body := `{"user_id":"u3","oidc_subject":"carol-sub"}`
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/users/setIdentity", body, false))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/users/setIdentity", body, true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"identity":{"user_id":"u3","oidc_subject":"carol-sub"}}`, rec.Body.String())
+
+	require.Len(t, db.entries, 1)
+	assert.Equal(t, "users:setIdentity", db.entries[0].Action)
+	assert.Equal(t, "u3", db.entries[0].Target)
+
+	rec, _ = oidcCallback(t, s, idp, map[string]any{"sub": "carol-sub"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var pair jwt.TokenPair
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&pair))
+	claims, err := s.jwtService.ValidateToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "u3", claims.UserID)
+	assert.False(t, db.autoProvision)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/users/setIdentity", `{"user_id":"u1","oidc_subject":"carol-sub"}`, true))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, constants.VALIDATION_ERROR, decodeErrorCode(t, rec))
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/users/setIdentity", `{"user_id":"u3"}`, true))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+type validator interface {
+	Validate() error
+}
+
+func decodeJSON(r *http.Request, dst validator) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		var maxBytesErr *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &maxBytesErr):
+			return apierror.ErrPayloadTooLarge
+		case errors.Is(err, io.EOF):
+			return apierror.Validation("validation.empty_body", nil)
+		case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+			return apierror.Validation("validation.invalid_json", nil)
+		case errors.As(err, &typeErr):
+			return apierror.Validation("validation.invalid_type", map[string]string{"field": typeErr.Field})
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return apierror.Validation("validation.unknown_field", map[string]string{"field": strings.TrimPrefix(err.Error(), "json: unknown field ")})
+		default:
+			return apierror.Validation("validation.invalid_body", nil)
+		}
+	}
+
+	if decoder.More() {
+		return apierror.Validation("validation.single_object", nil)
+	}
+
+	return dst.Validate()
+}
+
+func queryID(r *http.Request, name string) (string, error) {
+	value := r.URL.Query().Get(name)
+	if err := models.ValidateID(name, value); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+func queryName(r *http.Request, name string) (string, error) {
+	value := r.URL.Query().Get(name)
+	if err := models.ValidateName(name, value); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
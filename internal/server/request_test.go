@@ -0,0 +1,172 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/i18n"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{
+			name: "valid request",
+			body: `{"pull_request_id":"pr-1","pull_request_name":"Add search","author_id":"u1"}`,
+		},
+		{
+			name:    "empty body",
+			body:    ``,
+			wantErr: "request body is empty",
+		},
+		{
+			name:    "malformed json",
+			body:    `{"pull_request_id":`,
+			wantErr: "request body is not valid JSON",
+		},
+		{
+			name:    "unknown field",
+			body:    `{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1","extra":1}`,
+			wantErr: `unknown field "extra"`,
+		},
+		{
+			name:    "missing required field",
+			body:    `{"pull_request_id":"pr-1","author_id":"u1"}`,
+			wantErr: "pull_request_name is required",
+		},
+		{
+			name:    "invalid id format",
+			body:    `{"pull_request_id":"pr 1","pull_request_name":"x","author_id":"u1"}`,
+			wantErr: "pull_request_id has invalid format",
+		},
+		{
+			name:    "wrong type",
+			body:    `{"pull_request_id":1,"pull_request_name":"x","author_id":"u1"}`,
+			wantErr: "field pull_request_id has invalid type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(tt.body))
+
+			var req models.PullRequestCreateRequest
+			err := decodeJSON(r, &req)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var apiErr *apierror.Error
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, constants.VALIDATION_ERROR, apiErr.Code)
+			assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+			assert.Equal(t, tt.wantErr, apiErr.Message(i18n.English))
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "domain error from database",
+			err:        fmt.Errorf("wrapped: %w", database.ErrPRExists),
+			wantStatus: http.StatusConflict,
+			wantCode:   constants.PR_EXISTS,
+		},
+		{
+			name:       "internal error hides details",
+			err:        errors.New(`ERROR: relation "pull_requests" does not exist (SQLSTATE 42P01)`),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   constants.INTERNAL_ERROR,
+		},
+	}
+
+	s := &Server{catalog: i18n.New(i18n.English), log: discardLogger()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.writeError(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			var resp models.ErrorResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantCode, resp.Error.Code)
+			assert.NotContains(t, resp.Error.Message, "SQLSTATE")
+		})
+	}
+}
+
+func TestWriteError_Localized(t *testing.T) {
+	tests := []struct {
+		name           string
+		defaultLang    string
+		acceptLanguage string
+		err            error
+		wantLang       string
+		wantMessage    string
+	}{
+		{
+			name:           "russian requested",
+			defaultLang:    i18n.English,
+			acceptLanguage: "ru-RU,ru;q=0.9,en;q=0.8",
+			err:            apierror.ErrNotFound,
+			wantLang:       i18n.Russian,
+			wantMessage:    "ресурс не найден",
+		},
+		{
+			name:           "unsupported language falls back to default",
+			defaultLang:    i18n.Russian,
+			acceptLanguage: "de-DE",
+			err:            apierror.ErrNotFound,
+			wantLang:       i18n.Russian,
+			wantMessage:    "ресурс не найден",
+		},
+		{
+			name:           "validation error with params",
+			defaultLang:    i18n.English,
+			acceptLanguage: "ru",
+			err:            models.ValidateName("team_name", ""),
+			wantLang:       i18n.Russian,
+			wantMessage:    "team_name обязателен",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{catalog: i18n.New(tt.defaultLang), log: discardLogger()}
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			w := httptest.NewRecorder()
+			s.writeError(w, r, tt.err)
+
+			var resp models.ErrorResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			assert.Equal(t, tt.wantLang, w.Header().Get("Content-Language"))
+			assert.Equal(t, tt.wantMessage, resp.Error.Message)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+func (s *Server) setTeamReviewerSyncHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.TeamReviewerSyncRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var setting models.TeamReviewerSync
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		if setting, err = s.db.SetTeamReviewerSync(ctx, req.TeamName, *req.Enabled); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: setting.TeamName, after: setting}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"reviewer_sync": setting,
+	})
+}
+
+func (s *Server) reviewerSyncHandler(w http.ResponseWriter, r *http.Request) {
+	prID, err := queryID(r, "pull_request_id")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	status, err := s.db.GetReviewerSync(r.Context(), prID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"reviewer_sync": status,
+	})
+}
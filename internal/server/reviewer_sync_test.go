@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReviewerSyncDB struct {
+	fakeDB
+	teams map[string]bool
+	syncs map[string]models.ReviewerSync
+}
+
+func (f *fakeReviewerSyncDB) SetTeamReviewerSync(_ context.Context, teamName string, enabled bool) (models.TeamReviewerSync, error) {
+	if _, ok := f.teams[teamName]; !ok {
+		return models.TeamReviewerSync{}, database.ErrNotFound
+	}
+	f.teams[teamName] = enabled
+	return models.TeamReviewerSync{TeamName: teamName, Enabled: enabled}, nil
+}
+
+func (f *fakeReviewerSyncDB) GetReviewerSync(_ context.Context, prID string) (models.ReviewerSync, error) {
+	s, ok := f.syncs[prID]
+	if !ok {
+		return models.ReviewerSync{}, database.ErrNotFound
+	}
+	return s, nil
+}
+
+func newReviewerSyncTestServer(t *testing.T) (*Server, *fakeReviewerSyncDB) {
+	t.Helper()
+
+	db := &fakeReviewerSyncDB{
+		teams: map[string]bool{"backend": false},
+		syncs: map[string]models.ReviewerSync{},
+	}
+	return newTestServer(t, db), db
+}
+
+func TestTeamSetReviewerSync(t *testing.T) {
+	s, db := newReviewerSyncTestServer(t)
+	body := `{"team_name":"backend","enabled":true}`
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/setReviewerSync", body, false))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.False(t, db.teams["backend"])
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/setReviewerSync", body, true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.True(t, db.teams["backend"])
+	require.Len(t, db.entries, 1)
+	assert.Equal(t, "team:setReviewerSync", db.entries[0].Action)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/setReviewerSync", `{"team_name":"backend"}`, true))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, constants.VALIDATION_ERROR, decodeErrorCode(t, rec))
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/team/setReviewerSync", `{"team_name":"frontend","enabled":true}`, true))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPullRequestReviewerSync(t *testing.T) {
+	s, db := newReviewerSyncTestServer(t)
+	lastError := "github: unexpected status 502"
+	db.syncs["github:acme/api#7"] = models.ReviewerSync{
+		PullRequestID: "github:acme/api#7",
+		Provider:      models.ProviderGitHub,
+		Status:        models.ReviewerSyncPending,
+		Attempts:      2,
+		LastError:     &lastError,
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/pullRequest/reviewerSync?pull_request_id=github:acme/api%237", "", false))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		ReviewerSync models.ReviewerSync `json:"reviewer_sync"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, models.ReviewerSyncPending, resp.ReviewerSync.Status)
+	assert.Equal(t, 2, resp.ReviewerSync.Attempts)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/pullRequest/reviewerSync?pull_request_id=pr-1", "", false))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, constants.NOT_FOUND, decodeErrorCode(t, rec))
+}
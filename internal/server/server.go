@@ -2,18 +2,24 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/Parnishkaspb/avito/internal/helper"
+	"github.com/Parnishkaspb/avito/internal/i18n"
+	"github.com/Parnishkaspb/avito/internal/integrations"
 	"github.com/Parnishkaspb/avito/internal/jwt"
-	"github.com/jackc/pgx/v5"
-	"log"
+	"github.com/Parnishkaspb/avito/internal/logger"
+	"github.com/Parnishkaspb/avito/internal/metrics"
+	"github.com/Parnishkaspb/avito/internal/oidc"
+	"github.com/Parnishkaspb/avito/internal/ratelimit"
+	"log/slog"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/Parnishkaspb/avito/internal/apierror"
 	"github.com/Parnishkaspb/avito/internal/config"
 	"github.com/Parnishkaspb/avito/internal/constants"
 	"github.com/Parnishkaspb/avito/internal/database"
@@ -21,19 +27,47 @@ import (
 )
 
 type Server struct {
-	port       int
-	host       string
-	router     *http.ServeMux
-	db         database.DB
-	jwtService *jwt.Service
+	port           int
+	host           string
+	httpConfig     config.ServerConfig
+	router         *http.ServeMux
+	db             database.DB
+	jwtService     *jwt.Service
+	idempotencyTTL time.Duration
+	catalog        *i18n.Catalog
+	log            *slog.Logger
+	metrics        *metrics.Metrics
+	shuttingDown   atomic.Bool
+	ready          chan struct{}
+	settings       atomic.Pointer[runtimeSettings]
+	limiter        *ratelimit.Limiter
+	oidc           *oidc.Client
+	oidcConfig     config.OIDCConfig
+	integrations   *integrations.Syncer
+	integration    config.IntegrationConfig
+}
+
+type runtimeSettings struct {
+	reviewers models.ReviewerPolicy
 }
 
 type contextKey string
 
+const (
+	reviewersCount         = 2
+	defaultShutdownTimeout = 15 * time.Second
+)
+
+var defaultSettings = runtimeSettings{
+	reviewers: models.ReviewerPolicy{Count: reviewersCount, Strategy: models.ReviewerStrategyRandom},
+}
+
 const (
 	userIDKey   contextKey = "userID"
 	userNameKey contextKey = "userName"
 	userRoleKey contextKey = "userRole"
+
+	userOrgAdminKey contextKey = "userOrgAdmin"
 )
 
 func getUserRole(ctx context.Context) (bool, bool) {
@@ -41,34 +75,84 @@ func getUserRole(ctx context.Context) (bool, bool) {
 	return role, ok
 }
 
-func New(serverConfig config.ServerConfig, db database.DB, jwt_secret string) *Server {
-	jwtConfig := jwt.Config{
-		SecretKey:       jwt_secret,
+func getUserOrgAdmin(ctx context.Context) bool {
+	orgAdmin, _ := ctx.Value(userOrgAdminKey).(bool)
+	return orgAdmin
+}
+
+// NewTokenService создаёт сервис JWT с теми же параметрами, что и у сервера; нужен командам CLI.
+func NewTokenService(cfg config.JWTConfig) *jwt.Service {
+	return jwt.New(jwt.Config{
+		SecretKey:       cfg.Secret,
 		Issuer:          "avito",
-		AccessTokenTTL:  15 * time.Minute,
-		RefreshTokenTTL: 7 * 24 * time.Hour,
-	}
+		AccessTokenTTL:  cfg.AccessTokenTTL,
+		RefreshTokenTTL: cfg.RefreshTokenTTL,
+	})
+}
+
+func New(cfg *config.Config, db database.DB, log *slog.Logger, m *metrics.Metrics) *Server {
+	s := &Server{
+		port:           cfg.Server.Port,
+		host:           cfg.Server.Host,
+		httpConfig:     cfg.Server,
+		router:         http.NewServeMux(),
+		db:             db,
+		jwtService:     NewTokenService(cfg.JWT),
+		idempotencyTTL: cfg.Idempotency.TTL,
+		catalog:        i18n.New(cfg.I18n.DefaultLanguage),
+		log:            log.With(slog.String("component", "server")),
+		metrics:        m,
+		ready:          make(chan struct{}),
+		limiter:        ratelimit.New(cfg.RateLimit),
+		oidcConfig:     cfg.OIDC,
+		integrations:   integrations.NewSyncer(db),
+		integration:    cfg.Integration,
+	}
+	if cfg.OIDC.Enabled {
+		s.oidc = oidc.New(cfg.OIDC)
+	}
+	s.ApplyConfig(cfg)
+
+	return s
+}
+
+func (s *Server) ApplyConfig(cfg *config.Config) {
+	s.settings.Store(&runtimeSettings{
+		reviewers: models.ReviewerPolicy{
+			Count:    cfg.Reviewers.Count,
+			Strategy: cfg.Reviewers.Strategy,
+		},
+	})
+	s.jwtService.SetTokenTTL(cfg.JWT.AccessTokenTTL, cfg.JWT.RefreshTokenTTL)
+	s.limiter.Update(cfg.RateLimit)
+}
 
-	return &Server{
-		port:       serverConfig.Port,
-		host:       serverConfig.Host,
-		router:     http.NewServeMux(),
-		db:         db,
-		jwtService: jwt.New(jwtConfig),
+func (s *Server) currentSettings() *runtimeSettings {
+	if settings := s.settings.Load(); settings != nil {
+		return settings
 	}
+	return &defaultSettings
+}
+
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
 }
 
 func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			if apiKey := r.Header.Get(apiKeyHeader); apiKey != "" {
+				s.apiKeyAuth(w, r, apiKey, next)
+				return
+			}
+			s.writeError(w, r, apierror.ErrUnauthorized)
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+			s.writeError(w, r, apierror.NewKey(constants.UNAUTHORIZED, http.StatusUnauthorized, "auth.invalid_format"))
 			return
 		}
 
@@ -76,7 +160,7 @@ func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		claims, err := s.jwtService.ValidateToken(token)
 		if err != nil {
-			http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+			s.writeError(w, r, apierror.Wrap(err, constants.UNAUTHORIZED, http.StatusUnauthorized, "auth.invalid_token"))
 			return
 		}
 
@@ -84,7 +168,10 @@ func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		ctx = context.WithValue(ctx, userIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, userNameKey, claims.Name)
 		ctx = context.WithValue(ctx, userRoleKey, claims.Role)
+		ctx = context.WithValue(ctx, userOrgAdminKey, claims.OrgAdmin)
+		ctx = logger.WithAttrs(ctx, slog.String("user_id", claims.UserID))
 		r = r.WithContext(ctx)
+		setRequestUser(ctx, claims.UserID)
 
 		next(w, r)
 	}
@@ -95,7 +182,7 @@ func (s *Server) adminRoleMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		role, _ := getUserRole(r.Context())
 
 		if !role {
-			s.writeError(w, constants.NOT_FOUND, "resource not found", http.StatusUnauthorized)
+			s.writeError(w, r, apierror.ErrAdminOnly)
 			return
 		}
 
@@ -103,410 +190,398 @@ func (s *Server) adminRoleMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (s *Server) createTeamHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-		tmp := fmt.Sprintf("Ошибка обращения к createTeamHandler. Метод: %s - требуемый: POST", r.Method)
-		log.Fatal(tmp)
-		return
+func (s *Server) orgAdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !getUserOrgAdmin(r.Context()) {
+			s.writeError(w, r, apierror.ErrOrgAdminOnly)
+			return
+		}
+
+		next(w, r)
 	}
+}
 
+func (s *Server) createTeamHandler(w http.ResponseWriter, r *http.Request) {
 	var teamAdd models.RequestTeamAddResponse
-
-	if err := json.NewDecoder(r.Body).Decode(&teamAdd); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &teamAdd); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	exists, err := s.db.CreateTeam(context.Background(), teamAdd)
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		created, err := s.db.CreateTeam(ctx, teamAdd)
+		if err != nil {
+			return auditChange{}, err
+		}
+		if !created {
+			return auditChange{}, apierror.ErrTeamExists
+		}
+		return auditChange{target: teamAdd.TeamName, after: teamAdd}, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
-	if !exists {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		response := map[string]interface{}{
-			"team": teamAdd,
-		}
-		json.NewEncoder(w).Encode(response)
-	} else {
-		s.writeError(w, constants.TEAM_EXISTS, "team_name already exists", http.StatusBadRequest)
-	}
-
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"team": teamAdd,
+	})
 }
 
 func (s *Server) getTeamHandler(w http.ResponseWriter, r *http.Request) {
-	teamName := r.URL.Query().Get("team_name")
-	if teamName == "" {
-		http.Error(w, "team_name обязателен!", http.StatusBadRequest)
+	teamName, err := queryName(r, "team_name")
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	teamID, found, err := s.db.ReturnTeamID(context.Background(), teamName)
+	teamID, found, err := s.db.ReturnTeamID(r.Context(), teamName)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
 	if !found {
-		s.writeError(w, constants.NOT_FOUND, "resource not found", http.StatusNotFound)
+		s.writeError(w, r, apierror.ErrNotFound)
 		return
 	}
 
-	teamMembers, err := s.db.ReturnTeamMembersByTeamID(context.Background(), teamID)
+	teamMembers, err := s.db.ReturnTeamMembersByTeamID(r.Context(), teamID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
-	teamRequest := models.RequestTeamAddResponse{
+	writeJSON(w, http.StatusOK, models.RequestTeamAddResponse{
 		TeamName: teamName,
 		Members:  teamMembers,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(teamRequest)
+	})
 }
 
 func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	var req models.LoginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	if req.ID == "" || req.Name == "" {
-		http.Error(w, "Отсутствует из параметров обязательных параметров", http.StatusBadRequest)
+	// /login не проверяет учётные данные, поэтому org_admin здесь не выдаётся никогда: его дают
+	// только вход через SSO и команда admin bootstrap
+	tokenPair, err := s.issueTokens(r.Context(), req.ID, req.Name, false)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	role, err := s.db.CheckRoleUser(context.Background(), req.ID)
+	writeJSON(w, http.StatusOK, tokenPair)
+}
 
-	tokenPair, err := s.jwtService.GenerateTokenPair(req.ID, req.Name, role)
+func (s *Server) issueTokens(ctx context.Context, userID, name string, allowOrgAdmin bool) (*jwt.TokenPair, error) {
+	role, err := s.db.CheckRoleUser(ctx, userID)
 	if err != nil {
-		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+
+	orgAdmin := false
+	if allowOrgAdmin {
+		orgAdmin, err = s.db.CheckOrgAdmin(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tokenPair)
+	return s.jwtService.GenerateTokenPair(userID, name, role, orgAdmin)
 }
 
 func (s *Server) setIsActiveUserHandler(w http.ResponseWriter, r *http.Request) {
 	var userActive models.UserActive
-
-	if err := json.NewDecoder(r.Body).Decode(&userActive); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &userActive); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	exists, err := s.db.CheckUser(context.Background(), userActive.UserID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	}
+	var info models.UserActiveResponse
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		exists, err := s.db.CheckUser(ctx, userActive.UserID)
+		if err != nil {
+			return auditChange{}, err
+		}
+		if !exists {
+			return auditChange{}, apierror.ErrNotFound
+		}
 
-	if !exists {
-		s.writeError(w, constants.NOT_FOUND, "resource not found", http.StatusNotFound)
-		return
-	}
+		before, err := s.db.GetUser(ctx, userActive.UserID)
+		if err != nil {
+			return auditChange{}, err
+		}
+
+		if _, err = s.db.UpdateActive(ctx, userActive.UserID, *userActive.IsActive); err != nil {
+			return auditChange{}, err
+		}
 
-	_, err = s.db.UpdateActive(context.Background(), userActive.UserID, userActive.IsActive)
+		if info, err = s.db.GetUser(ctx, userActive.UserID); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: userActive.UserID, before: before, after: info}, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
+		return
 	}
 
-	info, err := s.db.GetUser(context.Background(), userActive.UserID)
-
-	response := map[string]models.UserActiveResponse{
+	writeJSON(w, http.StatusOK, map[string]models.UserActiveResponse{
 		"user": info,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 func (s *Server) getReviewHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id обязателен!", http.StatusBadRequest)
+	userID, err := queryID(r, "user_id")
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	pullRequests, err := s.db.ReturnUserReviewByUserID(context.Background(), userID)
+	pullRequests, err := s.db.ReturnUserReviewByUserID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
+		return
 	}
 
-	response := models.UserPullRequestsResponse{
+	writeJSON(w, http.StatusOK, models.UserPullRequestsResponse{
 		UserID:       userID,
 		PullRequests: pullRequests,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 func (s *Server) createPullRequestHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-		tmp := fmt.Sprintf("Ошибка обращения к createTeamHandler. Метод: %s - требуемый: POST", r.Method)
-		log.Fatal(tmp)
-		return
-	}
-
 	var PRCR models.PullRequestCreateRequest
-	if err := json.NewDecoder(r.Body).Decode(&PRCR); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	exists, err := s.db.CheckPR(context.Background(), PRCR.PullRequestId)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	}
-
-	if exists {
-		s.writeError(w, constants.PR_EXISTS, "PR id already exists", http.StatusConflict)
-		return
-	}
-
-	info, err := s.db.GetUser(context.Background(), PRCR.AuthorID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	}
-
-	if info.UserID == "" || info.TeamName == "" {
-		s.writeError(w, constants.NOT_FOUND, "resource not found", http.StatusNotFound)
+	if err := decodeJSON(r, &PRCR); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	teamMates, err := s.db.ReturnTeamMembersByUserID(context.Background(), info.UserID)
+	var pullRequest models.PullRequestResponse
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		if pullRequest, err = s.db.CreatePullRequestWithReviewers(ctx, PRCR, s.currentSettings().reviewers); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: PRCR.PullRequestId, after: pullRequest}, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
-	pullRequest, err := s.db.CreatePullRequest(context.Background(), PRCR.PullRequestId, PRCR.PullRequestName, PRCR.AuthorID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	}
-
-	randomTeamMates := helper.PickRandomTeamMates(teamMates, 2)
-
-	_, err = s.db.CreatePullRequestAssignedReview(context.Background(), pullRequest.PullRequestID, randomTeamMates)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	}
-
-	response := models.PullRequestResponse{
-		PullRequestID:     pullRequest.PullRequestID,
-		PullRequestName:   pullRequest.PullRequestName,
-		AuthorID:          pullRequest.AuthorID,
-		Status:            pullRequest.Status,
-		AssignedReviewers: randomTeamMates,
-	}
+	s.metrics.PullRequestCreated(len(pullRequest.AssignedReviewers))
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, pullRequest)
 }
 
 func (s *Server) mergePullRequestHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-		tmp := fmt.Sprintf("Ошибка обращения к createTeamHandler. Метод: %s - требуемый: POST", r.Method)
-		log.Fatal(tmp)
-		return
-	}
-
 	var req models.MergePRRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	exists, err := s.db.CheckPR(context.Background(), req.PullRequestID)
+	var (
+		res    models.PullRequest
+		merged bool
+	)
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		before := s.pullRequestSnapshot(ctx, req.PullRequestID)
 
+		var err error
+		if res, merged, err = s.db.MergePullRequest(ctx, req.PullRequestID); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: req.PullRequestID, before: before, after: res}, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	}
-
-	if !exists {
-		s.writeError(w, constants.NOT_FOUND, "resource not found", http.StatusConflict)
+		s.writeError(w, r, err)
 		return
 	}
 
-	res, err := s.db.MergePullRequest(context.Background(), req.PullRequestID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if merged {
+		s.metrics.PullRequestMerged()
 	}
 
-	response := map[string]models.PullRequest{
+	writeJSON(w, http.StatusOK, map[string]models.PullRequest{
 		"pr": res,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 func (s *Server) reassignPullRequestHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-		tmp := fmt.Sprintf("Ошибка обращения к createTeamHandler. Метод: %s - требуемый: POST", r.Method)
-		log.Fatal(tmp)
-		return
-	}
-
 	var req models.MergePRRequestReasing
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	status, err := s.db.CheckStatusPR(context.Background(), req.PullRequestID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	if !status {
-		s.writeError(w, constants.PR_MERGED, "cannot reassign on merged PR", http.StatusNotFound)
-		return
-	}
-
-	teamMates, err := s.db.GetAvailableTeamMatesForPR(context.Background(), req.PullRequestID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	}
-
-	if len(teamMates) == 0 {
-		s.writeError(w, constants.NO_CANDIDATE, "no active replacement candidate in team", http.StatusNotFound)
-		return
-	}
-
-	teamMateID := helper.PickRandomTeamMates(teamMates, 1)
-
-	err = s.db.ReassignPullRequest(context.Background(), req.PullRequestID, req.OldReviewerID, teamMateID[0])
+	var (
+		newReviewerID string
+		info          models.PullRequestResponse
+	)
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		before := s.pullRequestSnapshot(ctx, req.PullRequestID)
 
+		var err error
+		if newReviewerID, err = s.db.ReassignPullRequest(ctx, req.PullRequestID, req.OldReviewerID, s.currentSettings().reviewers); err != nil {
+			return auditChange{}, err
+		}
+		if info, err = s.db.PullRequestFullInformation(ctx, req.PullRequestID); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: req.PullRequestID, before: before, after: info}, nil
+	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			s.writeError(w, constants.NOT_ASSIGNED, "reviewer is not assigned to this PR", http.StatusNotFound)
-			return
+		if errors.Is(err, database.ErrNoCandidate) {
+			s.metrics.NoCandidate()
 		}
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
-	info, err := s.db.PullRequestFullInformation(context.Background(), req.PullRequestID)
-	response := map[string]any{
-		"pr":          info,
-		"replaced_by": teamMateID[0],
-	}
+	s.metrics.ReviewerReassigned()
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pr":          info,
+		"replaced_by": newReviewerID,
+	})
 }
 
 func (s *Server) getStatic(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	scopeUserID := ""
+	if !getUserOrgAdmin(r.Context()) {
+		scopeUserID, _ = r.Context().Value(userIDKey).(string)
+	}
 
-	metrics, totalPRs, totalTeams, err := s.db.GetTeamMetrics(r.Context())
+	metrics, totalPRs, totalTeams, err := s.db.GetTeamMetrics(r.Context(), scopeUserID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("ошибка при получении метрик: %v", err), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
-	response := models.StaticResponse{
+	writeJSON(w, http.StatusOK, models.StaticResponse{
 		TotalPRs:   totalPRs,
 		TotalTeams: totalTeams,
 		Teams:      metrics,
-	}
-
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, fmt.Sprintf("ошибка кодирования JSON: %v", err), http.StatusInternalServerError)
-		return
-	}
+	})
 }
 
 func (s *Server) setupRoutes() {
-	s.router.HandleFunc("POST /team/add", s.createTeamHandler)
-	s.router.HandleFunc("GET  /team/get", s.authMiddleware(s.getTeamHandler))
-	s.router.HandleFunc("GET  /statistic", s.getStatic)
-	s.router.HandleFunc("POST /login", s.loginHandler)
-	s.router.HandleFunc("POST /users/setIsActive", s.authMiddleware(s.adminRoleMiddleware(s.setIsActiveUserHandler)))
-	s.router.HandleFunc("GET  /users/getReview", s.authMiddleware(s.getReviewHandler))
-	s.router.HandleFunc("POST /pullRequest/create", s.authMiddleware(s.adminRoleMiddleware(s.createPullRequestHandler)))
-	s.router.HandleFunc("POST /pullRequest/merge", s.authMiddleware(s.adminRoleMiddleware(s.mergePullRequestHandler)))
-	s.router.HandleFunc("POST /pullRequest/reassign", s.authMiddleware(s.adminRoleMiddleware(s.reassignPullRequestHandler)))
+	s.handle(http.MethodPost, "/team/add", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.idempotencyMiddleware(s.createTeamHandler))))))
+	s.handle(http.MethodGet, "/team/get", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.getTeamHandler))))
+	s.handle(http.MethodPost, "/team/setReviewerSync", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.setTeamReviewerSyncHandler)))))
+	s.handle(http.MethodPost, "/team/setCodeowners", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.setCodeownersHandler)))))
+	s.handle(http.MethodGet, "/team/codeowners", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.getCodeownersHandler))))
+	s.handle(http.MethodPost, "/team/deleteCodeowners", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.deleteCodeownersHandler)))))
+	s.handle(http.MethodGet, "/statistic", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.getStatic))))
+	s.handle(http.MethodPost, "/login", s.ipRateLimitMiddleware(s.rateLimitMiddleware(s.loginHandler)))
+	if s.oidc != nil {
+		s.handle(http.MethodGet, "/auth/oidc/login", s.ipRateLimitMiddleware(s.rateLimitMiddleware(s.oidcLoginHandler)))
+		s.handle(http.MethodGet, "/auth/oidc/callback", s.ipRateLimitMiddleware(s.rateLimitMiddleware(s.oidcCallbackHandler)))
+	}
+	s.handle(http.MethodPost, "/users/setIsActive", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.adminRoleMiddleware(s.setIsActiveUserHandler)))))
+	s.handle(http.MethodPost, "/users/setIdentity", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.setUserIdentityHandler)))))
+	s.handle(http.MethodGet, "/users/getReview", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.getReviewHandler))))
+	s.handle(http.MethodPost, "/pullRequest/create", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.adminRoleMiddleware(s.idempotencyMiddleware(s.createPullRequestHandler))))))
+	s.handle(http.MethodPost, "/pullRequest/merge", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.adminRoleMiddleware(s.mergePullRequestHandler)))))
+	s.handle(http.MethodPost, "/pullRequest/reassign", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.adminRoleMiddleware(s.idempotencyMiddleware(s.reassignPullRequestHandler))))))
+	s.handle(http.MethodGet, "/pullRequest/reviewerSync", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.reviewerSyncHandler))))
+	s.handle(http.MethodPost, "/apiKeys/create", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.createAPIKeyHandler)))))
+	s.handle(http.MethodGet, "/apiKeys/list", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.listAPIKeysHandler)))))
+	s.handle(http.MethodPost, "/apiKeys/revoke", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.revokeAPIKeyHandler)))))
+	s.handle(http.MethodGet, "/audit", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.auditHandler)))))
+	s.handle(http.MethodPost, "/webhooks/create", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.createWebhookHandler)))))
+	s.handle(http.MethodGet, "/webhooks/list", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.listWebhooksHandler)))))
+	s.handle(http.MethodPost, "/webhooks/delete", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.deleteWebhookHandler)))))
+	s.handle(http.MethodGet, "/webhooks/deliveries", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.webhookDeliveriesHandler)))))
+	s.handle(http.MethodPost, "/webhooks/redeliver", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.redeliverWebhookHandler)))))
+	s.handle(http.MethodPost, "/integrations/identities/set", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.setIdentityHandler)))))
+	s.handle(http.MethodGet, "/integrations/identities/list", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.listIdentitiesHandler)))))
+	s.handle(http.MethodPost, "/integrations/identities/delete", s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.orgAdminMiddleware(s.deleteIdentityHandler)))))
+	if s.integration.GitHub.Enabled {
+		s.handle(http.MethodPost, "/integrations/github/webhook", s.ipRateLimitMiddleware(s.rateLimitMiddleware(s.githubWebhookHandler)))
+	}
+	if s.integration.GitLab.Enabled {
+		s.handle(http.MethodPost, "/integrations/gitlab/webhook", s.ipRateLimitMiddleware(s.rateLimitMiddleware(s.gitlabWebhookHandler)))
+	}
+	s.handle(http.MethodGet, "/metrics", s.metricsHandler)
+	s.handle(http.MethodGet, "/healthz", s.healthzHandler)
+	s.handle(http.MethodGet, "/readyz", s.readyzHandler)
+	s.router.HandleFunc("/", s.notFoundHandler)
 }
 
 func (s *Server) RunServer(ctx context.Context) error {
 	s.setupRoutes()
 
 	server := &http.Server{
-		Addr:    ":" + strconv.Itoa(s.port),
-		Handler: s.router,
+		Addr:              ":" + strconv.Itoa(s.port),
+		Handler:           s.handler(),
+		ReadTimeout:       s.httpConfig.ReadTimeout,
+		ReadHeaderTimeout: s.httpConfig.ReadHeaderTimeout,
+		WriteTimeout:      s.httpConfig.WriteTimeout,
+		IdleTimeout:       s.httpConfig.IdleTimeout,
+		MaxHeaderBytes:    s.httpConfig.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(s.log.Handler(), slog.LevelWarn),
+	}
+
+	s.log.Info("starting http server", slog.String("host", s.host), slog.Int("port", s.port))
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return fmt.Errorf("http server failed to listen: %w", err)
 	}
 
-	started := make(chan bool, 1)
 	serverErr := make(chan error, 1)
 
 	go func() {
-		log.Printf("Запуск сервера на http://%s:%d", s.host, s.port)
-		started <- true
-
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			serverErr <- fmt.Errorf("ошибка сервера: %w", err)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			serverErr <- fmt.Errorf("http server failed: %w", err)
 		}
 	}()
 
-	<-started
-	log.Println("Сервер успешно запущен")
+	s.log.Info("http server started", slog.String("addr", listener.Addr().String()))
+	if s.ready != nil {
+		close(s.ready)
+	}
 
 	select {
 	case <-ctx.Done():
-		log.Println("Получен сигнал остановки...")
-		return s.gracefulShutdown(server)
 	case err := <-serverErr:
 		return err
 	}
+
+	// /readyz сразу начинает отвечать 503, но запросы принимаются ещё drain_delay,
+	// пока балансировщик не перестанет направлять трафик на инстанс
+	s.shuttingDown.Store(true)
+	s.log.Info("draining http server", slog.Duration("drain_delay", s.httpConfig.DrainDelay))
+	select {
+	case <-time.After(s.httpConfig.DrainDelay):
+	case err := <-serverErr:
+		return err
+	}
+
+	s.log.Info("shutting down http server")
+	return s.gracefulShutdown(server)
 }
 
 func (s *Server) gracefulShutdown(server *http.Server) error {
+	timeout := s.httpConfig.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Ошибка: %v", err)
+		s.log.Error("graceful shutdown failed", slog.Any("error", err))
 		return server.Close()
 	}
 
-	log.Println("Сервер остановлен")
+	s.log.Info("http server stopped")
 	return nil
 }
-
-func (s *Server) writeError(w http.ResponseWriter, code, msg string, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": map[string]string{
-			"code":    code,
-			"message": msg,
-		},
-	})
-}
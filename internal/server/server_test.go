@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePolicyDB struct {
+	fakeDB
+	policy models.ReviewerPolicy
+}
+
+func (f *fakePolicyDB) CreatePullRequestWithReviewers(_ context.Context, req models.PullRequestCreateRequest, policy models.ReviewerPolicy) (models.PullRequestResponse, error) {
+	f.policy = policy
+	return models.PullRequestResponse{PullRequestID: req.PullRequestId, AssignedReviewers: []string{}}, nil
+}
+
+func TestApplyConfig_SwapsReviewerPolicy(t *testing.T) {
+	db := &fakePolicyDB{}
+	s := newTestServer(t, db, func(cfg *config.Config) {
+		cfg.Reviewers = config.ReviewersConfig{Count: 2, Strategy: models.ReviewerStrategyRandom}
+	})
+
+	create := func() {
+		body := `{"pull_request_id":"pr-1","pull_request_name":"Add search","author_id":"u1"}`
+		rec := httptest.NewRecorder()
+		s.createPullRequestHandler(rec, httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	create()
+	assert.Equal(t, models.ReviewerPolicy{Count: 2, Strategy: models.ReviewerStrategyRandom}, db.policy)
+
+	s.ApplyConfig(&config.Config{
+		JWT:       testJWTConfig,
+		Reviewers: config.ReviewersConfig{Count: 3, Strategy: models.ReviewerStrategyLeastLoaded},
+	})
+
+	create()
+	assert.Equal(t, models.ReviewerPolicy{Count: 3, Strategy: models.ReviewerStrategyLeastLoaded}, db.policy)
+}
+
+func TestApplyConfig_TokenTTL(t *testing.T) {
+	s := newTestServer(t, &fakePolicyDB{})
+
+	pair, err := s.jwtService.GenerateTokenPair("u1", "Alice", true, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), pair.ExpiresIn)
+
+	cfg := &config.Config{JWT: testJWTConfig}
+	cfg.JWT.AccessTokenTTL = 5 * time.Minute
+	s.ApplyConfig(cfg)
+
+	pair, err = s.jwtService.GenerateTokenPair("u1", "Alice", true, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), pair.ExpiresIn)
+}
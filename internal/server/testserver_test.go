@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/i18n"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+var testJWTConfig = config.JWTConfig{Secret: "0123456789abcdef-test", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestServer собирает сервер со всеми маршрутами поверх фейковой БД; opts дополняют
+// минимальный конфиг тем, что нужно конкретному тесту.
+func newTestServer(t *testing.T, db database.DB, opts ...func(cfg *config.Config)) *Server {
+	t.Helper()
+
+	cfg := &config.Config{JWT: testJWTConfig}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	s := New(cfg, db, discardLogger(), nil)
+	s.setupRoutes()
+	return s
+}
+
+// newRouterTestServer — сервер без зависимостей для тестов middleware: маршруты регистрирует сам тест.
+func newRouterTestServer() *Server {
+	return &Server{router: http.NewServeMux(), catalog: i18n.New(i18n.English), log: discardLogger()}
+}
+
+// fakeDB — основа фейков БД в тестах сервера: методы, которые тест не переопределил, паникуют
+// на встроенном nil database.DB, а транзакции и журнал аудита работают в памяти.
+type fakeDB struct {
+	database.DB
+	auditMu  sync.Mutex
+	entries  []models.AuditEntry
+	filter   models.AuditFilter
+	writeErr error
+}
+
+func (f *fakeDB) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *fakeDB) WriteAuditEntry(_ context.Context, entry models.AuditEntry) error {
+	f.auditMu.Lock()
+	defer f.auditMu.Unlock()
+
+	if f.writeErr != nil {
+		return f.writeErr
+	}
+	entry.ID = int64(len(f.entries) + 1)
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func (f *fakeDB) ListAuditEntries(_ context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	f.auditMu.Lock()
+	defer f.auditMu.Unlock()
+
+	f.filter = filter
+	page := make([]models.AuditEntry, 0, filter.Limit)
+	for i := len(f.entries) - 1; i >= 0 && len(page) < filter.Limit; i-- {
+		if filter.Cursor == 0 || f.entries[i].ID < filter.Cursor {
+			page = append(page, f.entries[i])
+		}
+	}
+	return page, nil
+}
+
+func (f *fakeDB) PullRequestFullInformation(_ context.Context, _ string) (models.PullRequestResponse, error) {
+	return models.PullRequestResponse{}, database.ErrNotFound
+}
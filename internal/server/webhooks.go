@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Parnishkaspb/avito/internal/apierror"
+	"github.com/Parnishkaspb/avito/internal/models"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretBytes  = 32
+)
+
+var deliveryStatuses = []string{models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func (s *Server) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookCreateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+
+	createdBy, _ := r.Context().Value(userIDKey).(string)
+
+	var sub models.WebhookSubscription
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		sub, err = s.db.CreateWebhookSubscription(ctx, models.WebhookSubscription{
+			URL:        req.URL,
+			EventTypes: req.EventTypes,
+			CreatedBy:  createdBy,
+			Secret:     secret,
+		})
+		if err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: sub.ID, after: sub}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "webhook subscription created",
+		slog.String("subscription_id", sub.ID), slog.Any("event_types", sub.EventTypes))
+
+	writeJSON(w, http.StatusCreated, models.WebhookCreateResponse{Secret: secret, Subscription: sub})
+}
+
+func (s *Server) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subs, err := s.db.ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"subscriptions": subs,
+	})
+}
+
+func (s *Server) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookDeleteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var sub models.WebhookSubscription
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		if sub, err = s.db.DeleteWebhookSubscription(ctx, req.ID); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: sub.ID, before: sub}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "webhook subscription deleted", slog.String("subscription_id", sub.ID))
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"subscription": sub,
+	})
+}
+
+func (s *Server) webhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.WebhookDeliveryFilter{
+		SubscriptionID: query.Get("subscription_id"),
+		Status:         query.Get("status"),
+	}
+
+	if filter.Status != "" && !slices.Contains(deliveryStatuses, filter.Status) {
+		s.writeError(w, r, apierror.Validation("validation.invalid_format", map[string]string{"field": "status"}))
+		return
+	}
+
+	var err error
+	if filter.Cursor, filter.Limit, err = parsePage(query); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	deliveries, err := s.db.ListWebhookDeliveries(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	page := models.WebhookDeliveryPage{Deliveries: deliveries}
+	if len(deliveries) == filter.Limit {
+		next := deliveries[len(deliveries)-1].ID
+		page.NextCursor = &next
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) redeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookRedeliverRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var delivery models.WebhookDelivery
+	err := s.audited(r, func(ctx context.Context) (auditChange, error) {
+		var err error
+		if delivery, err = s.db.RedeliverWebhook(ctx, req.ID); err != nil {
+			return auditChange{}, err
+		}
+		return auditChange{target: strconv.FormatInt(delivery.ID, 10), after: delivery}, nil
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"delivery": delivery,
+	})
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/constants"
+	"github.com/Parnishkaspb/avito/internal/database"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWebhookDB struct {
+	fakeDB
+	subs       []models.WebhookSubscription
+	deliveries []models.WebhookDelivery
+	filter     models.WebhookDeliveryFilter
+}
+
+func (f *fakeWebhookDB) CreateWebhookSubscription(_ context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	sub.ID = "sub-1"
+	sub.CreatedAt = time.Now()
+	f.subs = append(f.subs, sub)
+	return sub, nil
+}
+
+func (f *fakeWebhookDB) ListWebhookSubscriptions(_ context.Context) ([]models.WebhookSubscription, error) {
+	return f.subs, nil
+}
+
+func (f *fakeWebhookDB) DeleteWebhookSubscription(_ context.Context, id string) (models.WebhookSubscription, error) {
+	for i, sub := range f.subs {
+		if sub.ID == id {
+			f.subs = append(f.subs[:i], f.subs[i+1:]...)
+			return sub, nil
+		}
+	}
+	return models.WebhookSubscription{}, database.ErrNotFound
+}
+
+func (f *fakeWebhookDB) ListWebhookDeliveries(_ context.Context, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	f.filter = filter
+	page := make([]models.WebhookDelivery, 0, filter.Limit)
+	for i := len(f.deliveries) - 1; i >= 0 && len(page) < filter.Limit; i-- {
+		if filter.Cursor == 0 || f.deliveries[i].ID < filter.Cursor {
+			page = append(page, f.deliveries[i])
+		}
+	}
+	return page, nil
+}
+
+func (f *fakeWebhookDB) RedeliverWebhook(_ context.Context, id int64) (models.WebhookDelivery, error) {
+	for i, d := range f.deliveries {
+		if d.ID == id {
+			f.deliveries[i].Status = models.DeliveryPending
+			f.deliveries[i].Attempts = 0
+			return f.deliveries[i], nil
+		}
+	}
+	return models.WebhookDelivery{}, database.ErrNotFound
+}
+
+func newWebhookTestServer(t *testing.T) (*Server, *fakeWebhookDB) {
+	t.Helper()
+
+	db := &fakeWebhookDB{}
+	return newTestServer(t, db), db
+}
+
+func TestWebhooks_CreateListDelete(t *testing.T) {
+	s, db := newWebhookTestServer(t)
+	body := `{"url":"https://chat.example.com/hook","event_types":["pull_request.merged","user.deactivated"]}`
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/webhooks/create", body, false))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/webhooks/create", body, true))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created models.WebhookCreateResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+	assert.True(t, strings.HasPrefix(created.Secret, webhookSecretPrefix))
+	assert.Equal(t, "u1", created.Subscription.CreatedBy)
+	require.Len(t, db.subs, 1)
+	assert.Equal(t, created.Secret, db.subs[0].Secret)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/webhooks/list", "", true))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), created.Secret)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/webhooks/delete", `{"id":"sub-1"}`, true))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, db.subs)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/webhooks/delete", `{"id":"sub-1"}`, true))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.Len(t, db.entries, 2)
+	assert.Equal(t, "webhooks:create", db.entries[0].Action)
+	assert.Equal(t, "webhooks:delete", db.entries[1].Action)
+}
+
+func TestWebhooks_CreateValidation(t *testing.T) {
+	s, db := newWebhookTestServer(t)
+
+	for _, body := range []string{
+		`{"url":"ftp://example.com","event_types":["pull_request.merged"]}`,
+		`{"url":"https://example.com","event_types":[]}`,
+		`{"url":"https://example.com","event_types":["pull_request.closed"]}`,
+		`{"url":"https://example.com","event_types":["user.deactivated","user.deactivated"]}`,
+	} {
+		rec := httptest.NewRecorder()
+		s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/webhooks/create", body, true))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		assert.Equal(t, constants.VALIDATION_ERROR, decodeErrorCode(t, rec))
+	}
+	assert.Empty(t, db.subs)
+}
+
+func TestWebhooks_DeliveriesAndRedeliver(t *testing.T) {
+	s, db := newWebhookTestServer(t)
+	for id := int64(1); id <= 3; id++ {
+		db.deliveries = append(db.deliveries, models.WebhookDelivery{
+			ID: id, SubscriptionID: "sub-1", EventType: models.EventPullRequestCreated, Status: models.DeliveryFailed, Attempts: 10,
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/webhooks/deliveries?subscription_id=sub-1&status=failed&limit=2", "", true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var page models.WebhookDeliveryPage
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Deliveries, 2)
+	require.NotNil(t, page.NextCursor)
+	assert.Equal(t, int64(2), *page.NextCursor)
+	assert.Equal(t, models.WebhookDeliveryFilter{SubscriptionID: "sub-1", Status: "failed", Limit: 2}, db.filter)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodGet, "/webhooks/deliveries?status=lost", "", true))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, authRequest(t, s, http.MethodPost, "/webhooks/redeliver", `{"id":3}`, true))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, models.DeliveryPending, db.deliveries[2].Status)
+	assert.Zero(t, db.deliveries[2].Attempts)
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxStatementLength = 2048
+
+type QueryTracer struct{}
+
+func NewQueryTracer() *QueryTracer {
+	return &QueryTracer{}
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = t.start(ctx, conn, "db "+operation(data.SQL),
+		attribute.String("db.query.text", truncate(data.SQL)),
+		attribute.String("db.operation.name", operation(data.SQL)),
+	)
+	return ctx
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	end(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+func (t *QueryTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx, _ = t.start(ctx, conn, "db COPY",
+		attribute.String("db.operation.name", "COPY"),
+		attribute.String("db.collection.name", data.TableName.Sanitize()),
+	)
+	return ctx
+}
+
+func (t *QueryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	end(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+func (t *QueryTracer) start(ctx context.Context, conn *pgx.Conn, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system.name", "postgresql"))
+	if conn != nil {
+		cfg := conn.Config()
+		attrs = append(attrs,
+			attribute.String("db.namespace", cfg.Database),
+			attribute.String("server.address", cfg.Host),
+			attribute.Int("server.port", int(cfg.Port)),
+		)
+	}
+
+	return otel.Tracer(InstrumentationName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+func end(ctx context.Context, rowsAffected int64, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.response.rows_affected", rowsAffected))
+
+	if err != nil && err != pgx.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+func truncate(sql string) string {
+	if len(sql) <= maxStatementLength {
+		return sql
+	}
+	return sql[:maxStatementLength]
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func withRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestQueryTracer_Query(t *testing.T) {
+	recorder := withRecorder(t)
+	tracer := NewQueryTracer()
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL: "  select id from pull_requests where id = $1",
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "db SELECT", spans[0].Name())
+	assert.Equal(t, "postgresql", attributeValue(spans[0], "db.system.name").AsString())
+	assert.Equal(t, int64(1), attributeValue(spans[0], "db.response.rows_affected").AsInt64())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
+
+func TestQueryTracer_Error(t *testing.T) {
+	recorder := withRecorder(t)
+	tracer := NewQueryTracer()
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "INSERT INTO teams(name) VALUES($1)"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("duplicate key")})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestQueryTracer_NoRowsIsNotError(t *testing.T) {
+	recorder := withRecorder(t)
+	tracer := NewQueryTracer()
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: pgx.ErrNoRows})
+
+	assert.Equal(t, codes.Unset, recorder.Ended()[0].Status().Code)
+}
+
+func TestQueryTracer_ParentSpan(t *testing.T) {
+	recorder := withRecorder(t)
+	tracer := NewQueryTracer()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/Parnishkaspb/avito/internal/queue"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	maxResponseBytes = 64 << 10
+)
+
+type Store interface {
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingWebhook, error)
+	RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt models.WebhookAttempt) error
+}
+
+type Dispatcher struct {
+	store  Store
+	poller queue.Poller[models.PendingWebhook]
+	retry  queue.Retry
+	client *http.Client
+	log    *slog.Logger
+	now    func() time.Time
+}
+
+func NewDispatcher(store Store, cfg config.WebhooksConfig, log *slog.Logger) *Dispatcher {
+	d := &Dispatcher{
+		store: store,
+		retry: queue.Retry{MaxAttempts: cfg.MaxAttempts, BackoffBase: cfg.BackoffBase, BackoffMax: cfg.BackoffMax},
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		log: log.With(slog.String("component", "webhooks")),
+		now: time.Now,
+	}
+	d.poller = queue.Poller[models.PendingWebhook]{
+		PollInterval: cfg.PollInterval,
+		BatchSize:    cfg.BatchSize,
+		Lease:        2 * cfg.Timeout,
+		Claim:        store.ClaimWebhookDeliveries,
+		Handle:       d.deliver,
+		Log:          d.log,
+	}
+	return d
+}
+
+// Sign возвращает значение заголовка X-Webhook-Signature: HMAC-SHA256 от "<timestamp>.<body>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) Run(ctx context.Context) error {
+	return d.poller.Run(ctx)
+}
+
+// Dispatch доставляет одну пачку из очереди и возвращает её размер.
+func (d *Dispatcher) Dispatch(ctx context.Context) int {
+	return d.poller.Poll(ctx)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, p models.PendingWebhook) {
+	attempt := d.send(ctx, p)
+	if ctx.Err() != nil {
+		// остановка сервиса — попытку не засчитываем, доставка вернётся в очередь по истечении lease
+		return
+	}
+
+	log := d.log.With(
+		slog.Int64("delivery_id", p.DeliveryID),
+		slog.String("event_id", p.Event.ID),
+		slog.String("event_type", p.Event.Type),
+	)
+
+	if !attempt.Delivered {
+		attempts := p.Attempts + 1
+		if delay, ok := d.retry.Next(attempts); ok {
+			attempt.RetryAfter = delay
+			log.WarnContext(ctx, "webhook delivery failed, will retry",
+				slog.Int("attempt", attempts), slog.Duration("retry_after", attempt.RetryAfter), slog.String("error", attempt.Error))
+		} else {
+			log.ErrorContext(ctx, "webhook delivery failed permanently",
+				slog.Int("attempt", attempts), slog.String("error", attempt.Error))
+		}
+	}
+
+	if err := d.store.RecordWebhookAttempt(ctx, p.DeliveryID, attempt); err != nil {
+		log.ErrorContext(ctx, "failed to record webhook attempt", slog.Any("error", err))
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, p models.PendingWebhook) models.WebhookAttempt {
+	body, err := json.Marshal(p.Event)
+	if err != nil {
+		return models.WebhookAttempt{Error: err.Error()}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return models.WebhookAttempt{Error: err.Error()}
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "avito-webhooks")
+	req.Header.Set(HeaderEvent, p.Event.Type)
+	req.Header.Set(HeaderEventID, p.Event.ID)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(p.DeliveryID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(p.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return models.WebhookAttempt{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return models.WebhookAttempt{StatusCode: resp.StatusCode, Error: fmt.Sprintf("unexpected status %d", resp.StatusCode)}
+	}
+
+	return models.WebhookAttempt{Delivered: true, StatusCode: resp.StatusCode}
+}
@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Parnishkaspb/avito/internal/config"
+	"github.com/Parnishkaspb/avito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	mu       sync.Mutex
+	pending  []models.PendingWebhook
+	attempts map[int64]models.WebhookAttempt
+}
+
+func (f *fakeStore) ClaimWebhookDeliveries(_ context.Context, limit int, _ time.Duration) ([]models.PendingWebhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := min(limit, len(f.pending))
+	claimed := f.pending[:n]
+	f.pending = f.pending[n:]
+	return claimed, nil
+}
+
+func (f *fakeStore) RecordWebhookAttempt(_ context.Context, deliveryID int64, attempt models.WebhookAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.attempts == nil {
+		f.attempts = make(map[int64]models.WebhookAttempt)
+	}
+	f.attempts[deliveryID] = attempt
+	return nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func testConfig() config.WebhooksConfig {
+	return config.WebhooksConfig{
+		PollInterval: time.Second,
+		BatchSize:    10,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		BackoffBase:  10 * time.Second,
+		BackoffMax:   time.Minute,
+	}
+}
+
+func pendingWebhook(id int64, url string, attempts int) models.PendingWebhook {
+	return models.PendingWebhook{
+		DeliveryID: id,
+		Attempts:   attempts,
+		URL:        url,
+		Secret:     "s3cret",
+		Event: models.WebhookEvent{
+			ID:         "evt-" + strconv.FormatInt(id, 10),
+			Type:       models.EventPullRequestMerged,
+			OccurredAt: time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC),
+			Data:       json.RawMessage(`{"pull_request_id":"pr-1"}`),
+		},
+	}
+}
+
+func TestDispatch_SignsAndDelivers(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{pending: []models.PendingWebhook{pendingWebhook(1, srv.URL, 0)}}
+	d := NewDispatcher(store, testConfig(), discardLogger())
+	d.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	assert.Equal(t, 1, d.Dispatch(context.Background()))
+
+	require.NotNil(t, got)
+	assert.Equal(t, models.EventPullRequestMerged, got.Header.Get(HeaderEvent))
+	assert.Equal(t, "evt-1", got.Header.Get(HeaderEventID))
+	assert.Equal(t, "1", got.Header.Get(HeaderDelivery))
+	assert.Equal(t, "1700000000", got.Header.Get(HeaderTimestamp))
+	assert.Equal(t, Sign("s3cret", 1700000000, body), got.Header.Get(HeaderSignature))
+	assert.JSONEq(t, `{"id":"evt-1","type":"pull_request.merged","occurred_at":"2025-11-01T12:00:00Z","data":{"pull_request_id":"pr-1"}}`, string(body))
+
+	attempt := store.attempts[1]
+	assert.True(t, attempt.Delivered)
+	assert.Equal(t, http.StatusNoContent, attempt.StatusCode)
+}
+
+func TestDispatch_RetriesWithBackoffThenGivesUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{pending: []models.PendingWebhook{
+		pendingWebhook(1, srv.URL, 0),
+		pendingWebhook(2, srv.URL, 2),
+	}}
+	d := NewDispatcher(store, testConfig(), discardLogger())
+
+	assert.Equal(t, 2, d.Dispatch(context.Background()))
+
+	first := store.attempts[1]
+	assert.False(t, first.Delivered)
+	assert.Equal(t, http.StatusInternalServerError, first.StatusCode)
+	assert.Equal(t, 10*time.Second, first.RetryAfter)
+
+	last := store.attempts[2]
+	assert.False(t, last.Delivered)
+	assert.Zero(t, last.RetryAfter)
+}
+
+func TestDispatch_UnreachableEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	store := &fakeStore{pending: []models.PendingWebhook{pendingWebhook(1, url, 0)}}
+	d := NewDispatcher(store, testConfig(), discardLogger())
+	d.Dispatch(context.Background())
+
+	attempt := store.attempts[1]
+	assert.False(t, attempt.Delivered)
+	assert.Zero(t, attempt.StatusCode)
+	assert.NotEmpty(t, attempt.Error)
+	assert.Positive(t, attempt.RetryAfter)
+}